@@ -1,3 +1,5 @@
 module github.com/b0ch3nski/go-hosts-file
 
-go 1.18
+go 1.19
+
+require golang.org/x/net v0.33.0
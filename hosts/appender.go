@@ -0,0 +1,46 @@
+package hosts
+
+import (
+	"bufio"
+	"io"
+	"net/netip"
+)
+
+// Appender adds mappings to `Hosts` instance and immediately writes the new ones to provided `io.Writer`, which
+// allows growing hosts file without rewriting it.
+type Appender struct {
+	h     *Hosts
+	bufWr *bufio.Writer
+}
+
+// NewAppender creates `Appender` writing to provided `io.Writer`. Mappings already present in `Hosts` instance are
+// never written.
+func (h *Hosts) NewAppender(writer io.Writer) *Appender {
+	return &Appender{h: h, bufWr: bufio.NewWriter(writer)}
+}
+
+// Add adds IP:[]Host mapping like `Hosts.Add` and writes line(s) with aliases not mapped to the IP address before.
+// Output is buffered until `Flush`. Returns error of writing.
+func (a *Appender) Add(ip netip.Addr, alias ...string) error {
+	ip = a.h.normalizeIP(ip)
+	var fresh []string
+	known := len(a.h.ipToAlias[ip])
+	a.h.add(ip, alias, "", func(al string) {
+		// accepted alias is linked right before the call, so the set grows only for new aliases
+		if n := len(a.h.ipToAlias[ip]); n > known {
+			fresh = append(fresh, al)
+			known = n
+		}
+	}, nil)
+
+	if len(fresh) > 0 {
+		writeLines(a.bufWr, a.h.ipString(ip), fresh, "", defaultLineFormat)
+	}
+	_, errWrite := a.bufWr.Write(nil)
+	return errWrite
+}
+
+// Flush writes buffered lines to the underlying `io.Writer`.
+func (a *Appender) Flush() error {
+	return a.bufWr.Flush()
+}
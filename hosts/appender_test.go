@@ -0,0 +1,54 @@
+package hosts
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAppender(t *testing.T) {
+	h := New()
+	h.Add(ip_127_0_0_1, "localhost")
+
+	var buf bytes.Buffer
+	app := h.NewAppender(&buf)
+	equal(t, nil, app.Add(ip_127_0_0_1, "localhost", "new-name", "1bad"))
+	equal(t, nil, app.Add(ip_192_168_1_1, "router", "router"))
+	equal(t, nil, app.Add(ip_192_168_1_1, "router"))
+	equal(t, nil, app.Add(ip_192_168_1_2, "1bad"))
+	equal(t, "", buf.String())
+
+	equal(t, nil, app.Flush())
+	equal(t, "127.0.0.1 new-name\n192.168.1.1 router\n", buf.String())
+	equalStrArr(t, []string{"localhost", "new-name"}, h.GetAlias(ip_127_0_0_1))
+
+	// appended file holds all mappings together with the original content
+	r := New()
+	r.Read(strings.NewReader("127.0.0.1 localhost\n" + buf.String()))
+	equal(t, true, h.Equal(&r))
+
+	// long lines split
+	buf.Reset()
+	aliases := make([]string, 0, 20)
+	for i := 0; i < 20; i++ {
+		aliases = append(aliases, strings.Repeat("x", 30)+string(rune('a'+i)))
+	}
+	app.Add(ip_192_168_1_3, aliases...)
+	app.Flush()
+	equal(t, 3, strings.Count(buf.String(), "\n"))
+
+	// write error
+	errBroken := errors.New("broken")
+	broken := h.NewAppender(errWriter{errBroken})
+	broken.Add(ip_192_168_1_4, "host")
+	equal(t, errBroken, broken.Flush())
+}
+
+type errWriter struct {
+	err error
+}
+
+func (w errWriter) Write([]byte) (int, error) {
+	return 0, w.err
+}
@@ -0,0 +1,143 @@
+package hosts
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/netip"
+)
+
+const binaryMagic = "HST\x01"
+
+// ErrCorruptData is returned when decoded binary data is malformed.
+var ErrCorruptData = errors.New("corrupt binary data")
+
+// MarshalBinary implements `encoding.BinaryMarshaler` encoding all mappings in compact length-prefixed format.
+// Only mappings and wildcards are encoded (no priorities, timestamps etc.).
+func (h *Hosts) MarshalBinary() ([]byte, error) {
+	buf := bytes.NewBufferString(binaryMagic)
+	var tmp [binary.MaxVarintLen64]byte
+
+	putBytes := func(b []byte) {
+		buf.Write(tmp[:binary.PutUvarint(tmp[:], uint64(len(b)))])
+		buf.Write(b)
+	}
+
+	buf.Write(tmp[:binary.PutUvarint(tmp[:], uint64(len(h.ipToAlias)))])
+	for _, ip := range h.sortedIPs() {
+		addr, _ := ip.MarshalBinary()
+		putBytes(addr)
+
+		aliases := h.sortedAliases(ip)
+		buf.Write(tmp[:binary.PutUvarint(tmp[:], uint64(len(aliases)))])
+		for _, a := range aliases {
+			putBytes([]byte(a))
+		}
+	}
+
+	// wildcards (if any) follow in the same layout
+	if byIP := h.wildcardsByIP(); len(byIP) > 0 {
+		ips := make([]netip.Addr, 0, len(byIP))
+		for ip := range byIP {
+			ips = append(ips, ip)
+		}
+		sortAddrs(ips)
+
+		buf.Write(tmp[:binary.PutUvarint(tmp[:], uint64(len(ips)))])
+		for _, ip := range ips {
+			addr, _ := ip.MarshalBinary()
+			putBytes(addr)
+			buf.Write(tmp[:binary.PutUvarint(tmp[:], uint64(len(byIP[ip])))])
+			for _, d := range byIP[ip] {
+				putBytes([]byte(d))
+			}
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements `encoding.BinaryUnmarshaler` replacing all mappings with decoded ones. Data is fully
+// validated before replacing and `Hosts` instance is left untouched on error. Mappings are restored as encoded, so
+// options filtering or transforming added mappings (like `RequireSink` or `Transform`) don't apply. Aliases are checked
+// like by `ValidateAlias` (relaxed only by `AllowUnderscore` and `AllowLeadingDigit`) and invalid ones are reported
+// as corrupt data.
+func (h *Hosts) UnmarshalBinary(data []byte) error {
+	if !bytes.HasPrefix(data, []byte(binaryMagic)) {
+		return fmt.Errorf("%w: bad header", ErrCorruptData)
+	}
+	rd := bytes.NewReader(data[len(binaryMagic):])
+
+	getBytes := func() ([]byte, error) {
+		size, errSize := binary.ReadUvarint(rd)
+		if errSize != nil || size > uint64(rd.Len()) {
+			return nil, fmt.Errorf("%w: bad length", ErrCorruptData)
+		}
+		b := make([]byte, size)
+		rd.Read(b)
+		return b, nil
+	}
+
+	res := newHosts(0, h.opts)
+	if _, errEntries := res.unmarshalSection(rd, getBytes, res.link); errEntries != nil {
+		return errEntries
+	}
+	// optional wildcards section is never empty
+	if rd.Len() > 0 {
+		count, errWildcards := res.unmarshalSection(rd, getBytes, func(ip netip.Addr, domain string) {
+			if _, okD := res.wildcards[domain]; !okD {
+				res.wildcards[domain] = make(ipSet, 1)
+			}
+			res.wildcards[domain][ip] = struct{}{}
+		})
+		if errWildcards != nil {
+			return errWildcards
+		}
+		if count == 0 {
+			return fmt.Errorf("%w: empty wildcards", ErrCorruptData)
+		}
+	}
+
+	if rd.Len() > 0 {
+		return fmt.Errorf("%w: trailing data", ErrCorruptData)
+	}
+	*h = res
+	return nil
+}
+
+// unmarshalSection decodes IP addresses with their names passing every valid pair to store function. Returns amount
+// of decoded IP addresses.
+func (h *Hosts) unmarshalSection(rd *bytes.Reader, getBytes func() ([]byte, error),
+	store func(ip netip.Addr, name string)) (uint64, error) {
+	ipCount, errCount := binary.ReadUvarint(rd)
+	if errCount != nil || ipCount > uint64(rd.Len()) {
+		return 0, fmt.Errorf("%w: bad IP count", ErrCorruptData)
+	}
+
+	for i := uint64(0); i < ipCount; i++ {
+		addr, errAddr := getBytes()
+		if errAddr != nil {
+			return 0, errAddr
+		}
+		var ip netip.Addr
+		if errIp := ip.UnmarshalBinary(addr); errIp != nil || !ip.IsValid() {
+			return 0, fmt.Errorf("%w: bad IP address", ErrCorruptData)
+		}
+
+		aliasCount, errCount := binary.ReadUvarint(rd)
+		if errCount != nil || aliasCount == 0 || aliasCount > uint64(rd.Len()) {
+			return 0, fmt.Errorf("%w: bad alias count", ErrCorruptData)
+		}
+		for j := uint64(0); j < aliasCount; j++ {
+			alias, errAlias := getBytes()
+			if errAlias != nil {
+				return 0, errAlias
+			}
+			if h.validateAlias(string(alias)) != nil {
+				return 0, fmt.Errorf("%w: invalid alias %q", ErrCorruptData, alias)
+			}
+			store(ip, string(alias))
+		}
+	}
+	return ipCount, nil
+}
@@ -0,0 +1,49 @@
+package hosts
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMarshalBinary(t *testing.T) {
+	h := New()
+	h.ReadAll(strings.NewReader(exampleInput1), strings.NewReader(exampleInput2))
+
+	data, errMarshal := h.MarshalBinary()
+	if errMarshal != nil {
+		t.Fatal(errMarshal)
+	}
+
+	u := New()
+	if errUnmarshal := u.UnmarshalBinary(data); errUnmarshal != nil {
+		t.Fatal(errUnmarshal)
+	}
+	equal(t, h.Entries(), u.Entries())
+	testCommon(t, &u)
+
+	// corrupt data leaves instance untouched
+	corrupt := [][]byte{
+		nil,
+		[]byte("XXXX"),
+		data[:len(data)-1],
+		append(data, 0),
+		[]byte(binaryMagic + "\x01\x04\x7f\x00\x00\x01\x01\x041bad"),
+		[]byte(binaryMagic + "\x01\x03\x7f\x00\x00\x01\x02localhost"),
+	}
+	for _, c := range corrupt {
+		errUnmarshal := u.UnmarshalBinary(c)
+		equal(t, true, errors.Is(errUnmarshal, ErrCorruptData))
+		equal(t, h.Entries(), u.Entries())
+	}
+}
+
+func TestUnmarshalBinaryIgnoresAddOptions(t *testing.T) {
+	h := New()
+	h.Add(ip_192_168_1_1, "router")
+	data, _ := h.MarshalBinary()
+
+	u := NewWithOptions(Options{RequireSink: true, Family: FamilyV6, ExpandWWW: true, Transform: strings.ToUpper})
+	equal(t, nil, u.UnmarshalBinary(data))
+	equal(t, "192.168.1.1 router\n", u.String())
+}
@@ -0,0 +1,171 @@
+package hosts
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	blockBeginPrefix = "# BEGIN "
+	blockEndPrefix   = "# END "
+)
+
+var (
+	// ErrInvalidMarker is returned when managed block marker is empty or spans multiple lines.
+	ErrInvalidMarker = errors.New("invalid managed block marker")
+	// ErrUnterminatedBlock is returned when managed block has a begin marker but no matching end marker.
+	ErrUnterminatedBlock = errors.New("unterminated managed block")
+)
+
+// WriteBlock writes all mappings from `Hosts` instance surrounded by begin/end marker comments.
+func (h *Hosts) WriteBlock(writer io.Writer, marker string) error {
+	if errMarker := validateMarker(marker); errMarker != nil {
+		return errMarker
+	}
+	bufWr := bufio.NewWriter(writer)
+
+	bufWr.WriteString(blockBeginPrefix + marker + "\n")
+	if errWrite := h.Write(bufWr); errWrite != nil {
+		return errWrite
+	}
+	bufWr.WriteString(blockEndPrefix + marker + "\n")
+
+	return bufWr.Flush()
+}
+
+func validateMarker(marker string) error {
+	if strings.TrimSpace(marker) == "" || strings.ContainsAny(marker, "\r\n") {
+		return ErrInvalidMarker
+	}
+	return nil
+}
+
+// findBlock returns byte offsets of managed block (including marker lines) or -1 if block is absent.
+func findBlock(content []byte, marker string) (start, end int, err error) {
+	begin, finish := blockBeginPrefix+marker, blockEndPrefix+marker
+	start, end = -1, -1
+
+	for pos := 0; pos < len(content); {
+		lineEnd := bytes.IndexByte(content[pos:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(content)
+		} else {
+			lineEnd += pos + 1
+		}
+		line := strings.TrimSpace(string(content[pos:lineEnd]))
+
+		if start < 0 && line == begin {
+			start = pos
+		} else if start >= 0 && line == finish {
+			return start, lineEnd, nil
+		}
+		pos = lineEnd
+	}
+
+	if start >= 0 {
+		return -1, -1, ErrUnterminatedBlock
+	}
+	return -1, -1, nil
+}
+
+// replaceBlock returns content with managed block replaced by provided one or appended if absent.
+func replaceBlock(content []byte, marker string, block []byte) ([]byte, error) {
+	start, end, errFind := findBlock(content, marker)
+	if errFind != nil {
+		return nil, errFind
+	}
+
+	var buf bytes.Buffer
+	if start < 0 {
+		buf.Write(content)
+		if len(content) > 0 && content[len(content)-1] != '\n' {
+			buf.WriteByte('\n')
+		}
+		buf.Write(block)
+	} else {
+		buf.Write(content[:start])
+		buf.Write(block)
+		buf.Write(content[end:])
+	}
+	return buf.Bytes(), nil
+}
+
+// AppendToFile merges mappings from `Hosts` instance into managed block of file at provided path. Mappings already
+// present in the block are kept and duplicates are written only once. File is replaced atomically and keeps its
+// permissions.
+func (h *Hosts) AppendToFile(path, marker string) error {
+	return updateFile(path, marker, h, true)
+}
+
+// updateFile replaces (or merges into) managed block in file with mappings from provided `Hosts` instance.
+func updateFile(path, marker string, src *Hosts, merge bool) error {
+	info, errStat := os.Stat(path)
+	if errStat != nil {
+		return errStat
+	}
+	content, errRead := os.ReadFile(path)
+	if errRead != nil {
+		return errRead
+	}
+
+	if merge {
+		start, end, errFind := findBlock(content, marker)
+		if errFind != nil {
+			return fmt.Errorf("%s: %w", path, errFind)
+		}
+		merged := New()
+		if start >= 0 {
+			if errParse := merged.Read(bytes.NewReader(content[start:end])); errParse != nil {
+				return errParse
+			}
+		}
+		merged.Merge(src)
+		src = &merged
+	}
+
+	var block bytes.Buffer
+	if errBlock := src.WriteBlock(&block, marker); errBlock != nil {
+		return errBlock
+	}
+	out, errReplace := replaceBlock(content, marker, block.Bytes())
+	if errReplace != nil {
+		return fmt.Errorf("%s: %w", path, errReplace)
+	}
+
+	return writeFileAtomic(path, out, info.Mode().Perm())
+}
+
+// writeFileAtomic writes data to temporary file next to the target and renames it over the target.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, errCreate := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if errCreate != nil {
+		return fmt.Errorf("hosts file %s is not writable: %w", path, errCreate)
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	if _, errWrite := tmp.Write(data); errWrite != nil {
+		tmp.Close()
+		return errWrite
+	}
+	if errSync := tmp.Sync(); errSync != nil {
+		tmp.Close()
+		return errSync
+	}
+	if errClose := tmp.Close(); errClose != nil {
+		return errClose
+	}
+	if errChmod := os.Chmod(tmpName, perm); errChmod != nil {
+		return errChmod
+	}
+	if errRename := os.Rename(tmpName, path); errRename != nil {
+		return fmt.Errorf("hosts file %s is not writable: %w", path, errRename)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package hosts
+
+import (
+	"errors"
+	"io"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const exampleSystemHosts = `127.0.0.1 localhost
+# BEGIN dev
+10.0.0.1 stale
+# END dev
+::1 localhost
+`
+
+func TestUpdateFileManagedBlock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hosts")
+	if errWrite := os.WriteFile(path, []byte(exampleSystemHosts), 0640); errWrite != nil {
+		t.Fatal(errWrite)
+	}
+
+	h := New()
+	h.Add(ip_192_168_1_1, "fresh")
+
+	// existing block replaced, rest of the file untouched
+	if errUpdate := updateFile(path, "dev", &h, false); errUpdate != nil {
+		t.Fatal(errUpdate)
+	}
+	content, _ := os.ReadFile(path)
+	equal(t, "127.0.0.1 localhost\n# BEGIN dev\n192.168.1.1 fresh\n# END dev\n::1 localhost\n", string(content))
+
+	// permissions preserved
+	info, _ := os.Stat(path)
+	equal(t, os.FileMode(0640), info.Mode().Perm())
+
+	// missing block appended
+	if errUpdate := updateFile(path, "other", &h, false); errUpdate != nil {
+		t.Fatal(errUpdate)
+	}
+	content, _ = os.ReadFile(path)
+	equal(t, "127.0.0.1 localhost\n# BEGIN dev\n192.168.1.1 fresh\n# END dev\n::1 localhost\n"+
+		"# BEGIN other\n192.168.1.1 fresh\n# END other\n", string(content))
+}
+
+func TestAppendToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hosts")
+	if errWrite := os.WriteFile(path, []byte(exampleSystemHosts), 0600); errWrite != nil {
+		t.Fatal(errWrite)
+	}
+
+	h := New()
+	h.Add(netip.AddrFrom4([4]byte{10, 0, 0, 1}), "stale", "added")
+
+	// repeated runs don't grow the block
+	for i := 0; i < 3; i++ {
+		if errAppend := h.AppendToFile(path, "dev"); errAppend != nil {
+			t.Fatal(errAppend)
+		}
+	}
+
+	content, _ := os.ReadFile(path)
+	equal(t, 1, strings.Count(string(content), "stale"))
+	equal(t, 1, strings.Count(string(content), "added"))
+	equal(t, 1, strings.Count(string(content), "# BEGIN dev\n"))
+	equal(t, 2, strings.Count(string(content), "localhost"))
+
+	// block content merged
+	b := New()
+	b.Read(strings.NewReader(string(content)))
+	equalStrArr(t, []string{"stale", "added"}, b.GetAlias(netip.AddrFrom4([4]byte{10, 0, 0, 1})))
+}
+
+func TestReplaceBlockErrors(t *testing.T) {
+	_, errUnterminated := replaceBlock([]byte("# BEGIN dev\n10.0.0.1 stale\n"), "dev", nil)
+	equal(t, true, errors.Is(errUnterminated, ErrUnterminatedBlock))
+
+	h := New()
+	equal(t, ErrInvalidMarker, h.WriteBlock(io.Discard, ""))
+	equal(t, ErrInvalidMarker, h.WriteBlock(io.Discard, "multi\nline"))
+}
@@ -0,0 +1,38 @@
+package hosts
+
+import "errors"
+
+// ErrAliasCycle is returned when alias indirection would form a cycle.
+var ErrAliasCycle = errors.New("alias indirection cycle")
+
+// AddAlias records that `source` alias resolves to whatever IP addresses `target` alias resolves to (like DNS CNAME
+// record). Indirections may be chained and are followed by `GetIP` only when `source` has no IP addresses mapped
+// directly. Indirection forming a cycle is not recorded and `ErrAliasCycle` is returned. Invalid aliases are skipped.
+// Indirections are not written to hosts file as the format cannot express them.
+func (h *Hosts) AddAlias(source, target string) error {
+	if !h.validAlias(source) || !h.validAlias(target) {
+		return nil
+	}
+	for next, okNext := target, true; okNext; next, okNext = h.cnames[next] {
+		if next == source {
+			return ErrAliasCycle
+		}
+	}
+	h.cnames[source] = target
+	return nil
+}
+
+// resolveAlias follows alias indirections until alias with IP addresses mapped directly is found.
+func (h *Hosts) resolveAlias(alias string) string {
+	for hops := 0; hops <= len(h.cnames); hops++ {
+		if _, okIps := h.aliasToIp[alias]; okIps {
+			return alias
+		}
+		target, okTarget := h.cnames[alias]
+		if !okTarget {
+			return alias
+		}
+		alias = target
+	}
+	return alias
+}
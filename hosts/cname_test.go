@@ -0,0 +1,35 @@
+package hosts
+
+import "testing"
+
+func TestAddAlias(t *testing.T) {
+	h := New()
+	h.Add(ip_192_168_1_1, "target")
+	h.Add(ip_192_168_1_2, "direct")
+
+	// chained indirection
+	equal(t, nil, h.AddAlias("middle", "target"))
+	equal(t, nil, h.AddAlias("source", "middle"))
+	equal(t, []string{"192.168.1.1"}, ipArrStr(h.GetIP("source")))
+	equal(t, []string{"192.168.1.1"}, ipArrStr(h.GetIP("middle")))
+
+	// direct mappings take precedence
+	equal(t, nil, h.AddAlias("direct", "target"))
+	equal(t, []string{"192.168.1.2"}, ipArrStr(h.GetIP("direct")))
+
+	// cycles are rejected
+	equal(t, ErrAliasCycle, h.AddAlias("target", "source"))
+	equal(t, ErrAliasCycle, h.AddAlias("loop", "loop"))
+	equal(t, []string{"192.168.1.1"}, ipArrStr(h.GetIP("source")))
+
+	// dangling indirection
+	equal(t, nil, h.AddAlias("dangling", "missing"))
+	equal(t, 0, len(h.GetIP("dangling")))
+
+	// not written
+	equal(t, "192.168.1.1 target\n192.168.1.2 direct\n", h.String())
+
+	// removed with alias
+	h.DelByAlias("source")
+	equal(t, 0, len(h.GetIP("source")))
+}
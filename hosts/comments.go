@@ -0,0 +1,81 @@
+package hosts
+
+import (
+	"bufio"
+	"io"
+	"net/netip"
+	"strings"
+)
+
+// LeadingComments returns comment lines attached to specified IP:Host mapping (the first alias of commented line).
+// Requires `KeepComments` option.
+func (h *Hosts) LeadingComments(ip netip.Addr, alias string) []string {
+	return append([]string(nil), h.comments[mapping{h.normalizeIP(ip), alias}]...)
+}
+
+// readComment collects full line comment as pending leading comment. Empty line discards pending comments as they
+// don't directly precede an entry. Returns false for lines with content other than comment.
+func (h *Hosts) readComment(st *readState, raw string) bool {
+	line := strings.TrimSpace(raw)
+	switch {
+	case line == "":
+		st.comments = nil
+	case line[0] == '#':
+		st.comments = append(st.comments, line)
+	default:
+		return false
+	}
+	return true
+}
+
+// attachComments attaches leading comments to the first accepted mapping of just read line.
+func (h *Hosts) attachComments(m mapping, comments []string) {
+	h.comments[m] = append(h.comments[m], comments...)
+}
+
+// writeSections writes aliases of single IP address splitting them into sections before every alias with leading
+// comments, which are written above the section. Function `beforeComments` (if set) is called before comments of
+// every section.
+func (h *Hosts) writeSections(bufWr *bufio.Writer, ip netip.Addr, aliases []string, format lineFormat,
+	beforeComments func()) {
+	addr := h.ipString(ip)
+	start := 0
+	for i := 1; i <= len(aliases); i++ {
+		if i < len(aliases) && len(h.comments[mapping{ip, aliases[i]}]) == 0 {
+			continue
+		}
+		if comments := h.comments[mapping{ip, aliases[start]}]; len(comments) > 0 {
+			if beforeComments != nil {
+				beforeComments()
+			}
+			for _, c := range comments {
+				bufWr.WriteString(c)
+				bufWr.WriteString("\n")
+			}
+		}
+		writeLines(bufWr, addr, aliases[start:i], "", format)
+		start = i
+	}
+}
+
+// WriteCanonical writes all entries sorted by IP address and alias (canonical name first) with their leading comments
+// (see `KeepComments`), each commented section preceded by an empty line. Unlike `Write` it ignores `Lossless` and
+// `PreserveOrder` options, so output depends only on mappings and comments, which keeps it stable under version
+// control.
+func (h *Hosts) WriteCanonical(writer io.Writer) error {
+	cntWr := &countingWriter{writer: writer}
+	bufWr := bufio.NewWriter(cntWr)
+	separate := func() {
+		if cntWr.count+int64(bufWr.Buffered()) > 0 {
+			bufWr.WriteString("\n")
+		}
+	}
+	for _, ip := range h.sortedIPs() {
+		aliases := h.sortedAliases(ip)
+		if canon, okCanon := h.canonical[ip]; okCanon {
+			aliases = moveToFront(aliases, canon)
+		}
+		h.writeSections(bufWr, ip, aliases, defaultLineFormat, separate)
+	}
+	return bufWr.Flush()
+}
@@ -0,0 +1,81 @@
+package hosts
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+const exampleCommented = `# hosts file header
+
+# Local
+127.0.0.1 localhost
+# Ad servers
+#   (second line)
+0.0.0.0 ads.example.com
+0.0.0.0 tracker.example.com
+# Orphaned
+not-an-ip host
+192.168.1.1 router # inline comment
+`
+
+func TestKeepComments(t *testing.T) {
+	// dropped by default
+	d := New()
+	d.Read(strings.NewReader(exampleCommented))
+	equal(t, 0, strings.Count(d.String(), "#"))
+
+	h := NewWithOptions(Options{KeepComments: true})
+	h.Read(strings.NewReader(exampleCommented))
+
+	equal(t, []string{"# Local"}, h.LeadingComments(ip_127_0_0_1, "localhost"))
+	equal(t, []string{"# Ad servers", "#   (second line)"}, h.LeadingComments(netip.IPv4Unspecified(), "ads.example.com"))
+	equal(t, 0, len(h.LeadingComments(netip.IPv4Unspecified(), "tracker.example.com")))
+	equal(t, 0, len(h.LeadingComments(ip_192_168_1_1, "router")))
+	equal(t, "# Ad servers\n#   (second line)\n0.0.0.0 ads.example.com tracker.example.com\n"+
+		"# Local\n127.0.0.1 localhost\n192.168.1.1 router\n", h.String())
+
+	// comment follows its entry when filtered
+	var buf strings.Builder
+	h.WriteTopN(&buf, 1)
+	equal(t, "# Ad servers\n#   (second line)\n0.0.0.0 ads.example.com tracker.example.com\n", buf.String())
+
+	// comment deleted with its entry
+	h.DelByIP(ip_127_0_0_1)
+	h.Add(ip_127_0_0_1, "localhost")
+	equal(t, 0, len(h.LeadingComments(ip_127_0_0_1, "localhost")))
+
+	// entries sharing IP address keep their own comments
+	b := NewWithOptions(Options{KeepComments: true})
+	b.Read(strings.NewReader("# Ads\n0.0.0.0 a.com a2.com\n# Trackers\n0.0.0.0 b.com\n0.0.0.0 c.com\n"))
+	equal(t, "# Ads\n0.0.0.0 a.com a2.com\n# Trackers\n0.0.0.0 b.com c.com\n", b.String())
+
+	// comment goes with its alias only
+	b.unlink(netip.IPv4Unspecified(), "b.com")
+	equal(t, "# Ads\n0.0.0.0 a.com a2.com c.com\n", b.String())
+}
+
+func TestWriteCanonical(t *testing.T) {
+	const expected = "# Ad servers\n#   (second line)\n0.0.0.0 ads.example.com tracker.example.com\n\n" +
+		"# Local\n127.0.0.1 localhost\n192.168.1.1 router\n\n# Trackers\n192.168.1.1 tracker.org\n"
+
+	for _, opts := range []Options{{KeepComments: true}, {KeepComments: true, Lossless: true, PreserveOrder: true}} {
+		h := NewWithOptions(opts)
+		h.Read(strings.NewReader(exampleCommented + "# Trackers\n192.168.1.1 tracker.org\n"))
+
+		var buf strings.Builder
+		equal(t, nil, h.WriteCanonical(&buf))
+		equal(t, expected, buf.String())
+
+		// stable across writes and after reading own output
+		var again strings.Builder
+		h.WriteCanonical(&again)
+		equal(t, expected, again.String())
+
+		r := NewWithOptions(opts)
+		r.Read(strings.NewReader(expected))
+		again.Reset()
+		r.WriteCanonical(&again)
+		equal(t, expected, again.String())
+	}
+}
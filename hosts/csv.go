@@ -0,0 +1,61 @@
+package hosts
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+)
+
+// WriteCSV writes all mappings as `ip,alias` CSV rows (preceded by header row) sorted by IP address and alias.
+func (h *Hosts) WriteCSV(writer io.Writer) error {
+	csvWr := csv.NewWriter(writer)
+	csvWr.Write([]string{"ip", "alias"})
+	for _, m := range h.Mappings() {
+		csvWr.Write([]string{m.IP.String(), m.Alias})
+	}
+	csvWr.Flush()
+	return csvWr.Error()
+}
+
+// ReadCSV appends mappings read from `ip,alias[,alias...]` CSV rows validating them like `Read`. Header row is
+// detected automatically and skipped.
+func (h *Hosts) ReadCSV(reader io.Reader) error {
+	csvRd := csv.NewReader(reader)
+	csvRd.FieldsPerRecord = -1
+	csvRd.TrimLeadingSpace = true
+	st := h.newReadState(false)
+
+	for lineNum := 1; ; lineNum++ {
+		record, errRead := csvRd.Read()
+		if errRead != nil {
+			if errors.Is(errRead, io.EOF) {
+				return st.err()
+			}
+			return errRead
+		}
+		if lineNum == 1 && strings.EqualFold(record[0], "ip") {
+			continue
+		}
+
+		line := strings.Join(record, ",")
+		ip, errParse := ValidateIP(record[0])
+		if errParse != nil {
+			h.skipped(st, lineNum, line, ReasonInvalidIP, errParse)
+			continue
+		}
+		if len(record) == 1 {
+			h.skipped(st, lineNum, line, ReasonIPOnly, nil)
+			continue
+		}
+
+		var accept, reject func(string)
+		if h.opts.Strict {
+			accept = func(a string) { st.checkDuplicate(lineNum, h.normalizeIP(ip), a) }
+		}
+		if h.opts.Logger != nil || h.opts.Strict {
+			reject = func(a string) { h.skipped(st, lineNum, line, ReasonInvalidAlias, h.validateAlias(a)) }
+		}
+		h.add(ip, record[1:], "", accept, reject)
+	}
+}
@@ -0,0 +1,47 @@
+package hosts
+
+import (
+	"bytes"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestCSVRoundTrip(t *testing.T) {
+	h := New()
+	h.ReadAll(strings.NewReader(exampleInput1), strings.NewReader(exampleInput2))
+
+	var buf bytes.Buffer
+	if errWrite := h.WriteCSV(&buf); errWrite != nil {
+		t.Fatal(errWrite)
+	}
+	equal(t, true, strings.HasPrefix(buf.String(), "ip,alias\n127.0.0.1,localhost\n127.0.0.1,the-same\n"))
+
+	c := New()
+	if errRead := c.ReadCSV(&buf); errRead != nil {
+		t.Fatal(errRead)
+	}
+	equal(t, true, h.Equal(&c))
+	testCommon(t, &c)
+}
+
+func TestReadCSV(t *testing.T) {
+	const input = "127.0.0.1,localhost\n192.168.1.1, tabs,spaces\nnot-an-ip,host\n192.168.1.2\n172.16.0.1,1bad.org\n"
+
+	// no header
+	h := New()
+	if errRead := h.ReadCSV(strings.NewReader(input)); errRead != nil {
+		t.Fatal(errRead)
+	}
+	equal(t, []string{"localhost"}, h.GetAlias(ip_127_0_0_1))
+	equalStrArr(t, []string{"tabs", "spaces"}, h.GetAlias(ip_192_168_1_1))
+	equal(t, 2, h.Len())
+
+	// malformed CSV
+	equal(t, true, h.ReadCSV(strings.NewReader("127.0.0.1,\"broken\n")) != nil)
+
+	// no conflict between spellings of the same address
+	unmapped := NewWithOptions(Options{Strict: true, UnmapV4: true})
+	equal(t, nil, unmapped.ReadCSV(strings.NewReader("::ffff:192.168.1.1,router\n192.168.1.1,router\n")))
+	equal(t, []netip.Addr{ip_192_168_1_1}, unmapped.GetIP("router"))
+}
@@ -0,0 +1,40 @@
+package hosts
+
+import (
+	"bufio"
+	"fmt"
+	"go/token"
+	"io"
+	"strconv"
+)
+
+// WriteGoSource writes Go variable declaration building slice of `Entry` with all mappings from `Hosts` instance.
+// Output is gofmt-formatted, sorted and expects `hosts` and `net/netip` packages to be imported by the file including
+// it, which makes it suitable for `go:generate`.
+func (h *Hosts) WriteGoSource(writer io.Writer, varName string) error {
+	if !token.IsIdentifier(varName) {
+		return fmt.Errorf("invalid Go identifier: %q", varName)
+	}
+	bufWr := bufio.NewWriter(writer)
+
+	entries := h.Entries()
+	if len(entries) == 0 {
+		bufWr.WriteString("var " + varName + " = []hosts.Entry{}\n")
+		return bufWr.Flush()
+	}
+
+	bufWr.WriteString("var " + varName + " = []hosts.Entry{\n")
+	for _, e := range entries {
+		bufWr.WriteString("\t{IP: netip.MustParseAddr(" + strconv.Quote(e.IP.String()) + "), Aliases: []string{")
+		for i, a := range e.Aliases {
+			if i > 0 {
+				bufWr.WriteString(", ")
+			}
+			bufWr.WriteString(strconv.Quote(a))
+		}
+		bufWr.WriteString("}},\n")
+	}
+	bufWr.WriteString("}\n")
+
+	return bufWr.Flush()
+}
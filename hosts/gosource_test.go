@@ -0,0 +1,44 @@
+package hosts
+
+import (
+	"bytes"
+	"go/format"
+	"testing"
+)
+
+func TestWriteGoSource(t *testing.T) {
+	h := New()
+	h.Add(ip_192_168_1_1, "tabs", "spaces")
+	h.Add(ip_127_0_0_1, "localhost")
+
+	var buf bytes.Buffer
+	if errWrite := h.WriteGoSource(&buf, "table"); errWrite != nil {
+		t.Fatal(errWrite)
+	}
+	equal(t, `var table = []hosts.Entry{
+	{IP: netip.MustParseAddr("127.0.0.1"), Aliases: []string{"localhost"}},
+	{IP: netip.MustParseAddr("192.168.1.1"), Aliases: []string{"spaces", "tabs"}},
+}
+`, buf.String())
+
+	// gofmt-valid
+	formatted, errFormat := format.Source(buf.Bytes())
+	if errFormat != nil {
+		t.Fatal(errFormat)
+	}
+	equal(t, buf.String(), string(formatted))
+
+	// empty table
+	buf.Reset()
+	e := New()
+	e.WriteGoSource(&buf, "empty")
+	equal(t, "var empty = []hosts.Entry{}\n", buf.String())
+	formatted, errFormat = format.Source(buf.Bytes())
+	if errFormat != nil {
+		t.Fatal(errFormat)
+	}
+	equal(t, buf.String(), string(formatted))
+
+	// invalid identifier
+	equal(t, true, h.WriteGoSource(&buf, "not valid") != nil)
+}
@@ -3,34 +3,205 @@ package hosts
 import (
 	"bufio"
 	"bytes"
+	"fmt"
 	"io"
 	"net/netip"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const (
 	maxAliasesPerLine = 9
 	maxLineLength     = 255
+	utf8BOM           = "\xEF\xBB\xBF"
+)
+
+// Reasons of skipping passed to `Options.Logger`.
+const (
+	ReasonInvalidIP    = "invalid-ip"
+	ReasonInvalidAlias = "invalid-alias"
+	ReasonIPOnly       = "ip-only"
+	ReasonLineTooLong  = "line-too-long"
+	ReasonRejectedIP   = "rejected-ip"
+	ReasonMalformed    = "malformed"
 )
 
 var (
 	rgxHostsFileLine = regexp.MustCompile(`(\S+)+`)
-	rgxValidAlias    = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9-.]*[a-zA-Z0-9]$`)
 )
 
+var now = time.Now
+
 type strSet map[string]struct{}
 type ipSet map[netip.Addr]struct{}
 
+type mapping struct {
+	ip    netip.Addr
+	alias string
+}
+
+// Options controls optional behaviour of `Hosts` instance.
+type Options struct {
+	// PreserveIPText keeps original textual form of IP addresses read from file and writes it back verbatim as long as
+	// aliases of given address were not changed with `Add`.
+	PreserveIPText bool
+	// TrackTouched stamps every IP:Host mapping with the time of its last `Add` (or `Read`) which allows removing stale
+	// mappings with `Prune`. Timestamps are kept in additional map costing roughly 80 bytes (plus alias length) per
+	// mapping.
+	TrackTouched bool
+	// Logger is called by `Read` for every skipped line (or alias) with the reason of skipping.
+	Logger func(lineNum int, line, reason string)
+	// UnmapV4 converts IPv4-mapped IPv6 addresses (like `::ffff:192.168.1.1`) into plain IPv4 form so both spellings
+	// share the same mapping.
+	UnmapV4 bool
+	// TrackCanonical remembers the first alias added for every IP address as its canonical name (as hosts file format
+	// defines) and writes it first in the line.
+	TrackCanonical bool
+	// Lossless keeps every line read (including comments and whitespace) so writing unchanged table reproduces source
+	// byte by byte. Lines which mappings were removed are rewritten (or dropped) and new mappings are written after
+	// read lines. It costs memory for the whole source content.
+	Lossless bool
+	// AliasSeparators lists characters (like `,`) which split a single token into multiple aliases validated
+	// independently. Useful for malformed lists joining domains into one token. Note that `Read` treats `;` as comment.
+	AliasSeparators string
+	// Transform rewrites every alias passed to `Add` (or read) before validation, e.g. to lowercase it or strip `www.`
+	// prefix. Returning empty string drops the alias.
+	Transform func(alias string) string
+	// MaxLineBytes limits length of a line (including line ending) accepted by `Read`. Longer lines are skipped without
+	// being buffered, which protects from memory exhaustion on untrusted input. Zero means unlimited.
+	MaxLineBytes int
+	// RequireSink accepts only loopback or unspecified IP addresses, which prevents imported block list from redirecting
+	// hosts to routable addresses.
+	RequireSink bool
+	// Strict makes `Read` return `ReadErrors` describing every skipped line and every alias mapped to different IP
+	// addresses within single read. Valid mappings are still added.
+	Strict bool
+	// PreserveOrder keeps aliases of every IP address in order of their first addition (as listed in source file) which
+	// is then used by `GetAlias` and `Write`. Order is kept in additional slice per IP address.
+	PreserveOrder bool
+	// KeepComments attaches comment lines directly preceding an entry (like `# Ad servers`) to its first alias.
+	// `Write` emits them before a line starting with this alias (followed by the next aliases of the same IP address
+	// up to another commented one) and they are dropped together with the alias.
+	KeepComments bool
+	// SortedIndex maintains sorted index of IP addresses updated on every change, so writing doesn't sort all
+	// addresses again. Useful for large tables written after every small change.
+	SortedIndex bool
+	// Family accepts only IP addresses of given family. IPv4-mapped IPv6 addresses are IPv6 unless `UnmapV4` is set.
+	Family Family
+	// ContinueOnReaderError makes `ReadAll` read all readers even if some fail and return `ReadErrors` holding
+	// `ReaderError` of every failed one.
+	ContinueOnReaderError bool
+	// ExpandWWW adds `www.` variant of every added alias containing a dot (like `www.example.com` for `example.com`)
+	// and bare domain for every `www.` alias, so both are blocked at once. Variants are never expanded again.
+	ExpandWWW bool
+	// PreserveIPCase writes IPv6 addresses read with upper case hex digits (like `FE80::1`) in upper case canonical
+	// form, also after their aliases were changed. Unlike `PreserveIPText` it keeps only the casing, which avoids diff
+	// churn against sources using upper case without keeping non-canonical spellings.
+	PreserveIPCase bool
+	// CommentAtLineStartOrAfterSpace makes `Read` treat `#` (or `;`) as comment only at the start of a line or after
+	// whitespace, so a token like `host#1` is read whole (and rejected as invalid alias) instead of being truncated.
+	CommentAtLineStartOrAfterSpace bool
+	// AllowUnderscore accepts underscores inside aliases (like `my_service`), which are common in intranet hostnames
+	// despite not being valid in DNS names.
+	AllowUnderscore bool
+	// AllowLeadingDigit accepts aliases starting with a digit (like `1password.com`) as RFC 1123 permits. Aliases
+	// parsing as IP addresses are still rejected.
+	AllowLeadingDigit bool
+	// TrimTrailingDot strips trailing dot of fully qualified aliases (like `example.com.`) passed to `Add` (or read)
+	// before validation, so they are stored in the usual form instead of being rejected.
+	TrimTrailingDot bool
+	// TrimAliasSpace trims surrounding whitespace (including Unicode spaces like no-break space, which `Read` doesn't
+	// split on) of every alias passed to `Add` (or read) before validation, so aliases from messy input are kept.
+	TrimAliasSpace bool
+}
+
+// Family selects accepted IP address family.
+type Family int
+
+const (
+	FamilyBoth Family = iota
+	FamilyV4
+	FamilyV6
+)
+
+// Entry is a single IP address together with all its aliases.
+type Entry struct {
+	IP      netip.Addr
+	Aliases []string
+}
+
+// Mapping is a single IP:Host pair.
+type Mapping struct {
+	IP    netip.Addr
+	Alias string
+}
+
 // Hosts is the representation of IP-to-Host and Host-to-IP mappings.
 type Hosts struct {
 	ipToAlias map[netip.Addr]strSet
 	aliasToIp map[string]ipSet
+	ipText    map[netip.Addr]string
+	upperIP   ipSet
+	touched   map[mapping]time.Time
+	priority  map[mapping]int
+	ttl       map[mapping]time.Duration
+	cnames    map[string]string
+	wildcards map[string]ipSet
+	order     map[netip.Addr][]string
+	comments  map[mapping][]string
+	tags      map[mapping]strSet
+	canonical map[netip.Addr]string
+	seq       map[netip.Addr]uint64
+	opts      Options
+	*tableState
+}
+
+// tableState holds mutable state other than maps behind a pointer, so copies of `Hosts` value share all of it.
+type tableState struct {
+	index   []netip.Addr
+	nextSeq uint64
+	lines   []srcLine
+	bom     bool
 }
 
 // New creates empty `Hosts` instance.
 func New() Hosts {
-	return Hosts{ipToAlias: make(map[netip.Addr]strSet), aliasToIp: make(map[string]ipSet)}
+	return NewWithOptions(Options{})
+}
+
+// NewSized creates empty `Hosts` instance with both maps preallocated to capacity `n`, which is a hint for number of
+// IP addresses that will be loaded (there are at least as many aliases as IPs).
+func NewSized(n int) Hosts {
+	return newHosts(n, Options{})
+}
+
+// NewWithOptions creates empty `Hosts` instance with provided options.
+func NewWithOptions(opts Options) Hosts {
+	return newHosts(0, opts)
+}
+
+func newHosts(n int, opts Options) Hosts {
+	return Hosts{
+		ipToAlias:  make(map[netip.Addr]strSet, n),
+		aliasToIp:  make(map[string]ipSet, n),
+		ipText:     make(map[netip.Addr]string),
+		upperIP:    make(ipSet),
+		touched:    make(map[mapping]time.Time),
+		priority:   make(map[mapping]int),
+		ttl:        make(map[mapping]time.Duration),
+		cnames:     make(map[string]string),
+		wildcards:  make(map[string]ipSet),
+		order:      make(map[netip.Addr][]string),
+		comments:   make(map[mapping][]string),
+		tags:       make(map[mapping]strSet),
+		canonical:  make(map[netip.Addr]string),
+		seq:        make(map[netip.Addr]uint64, n),
+		tableState: &tableState{},
+		opts:       opts,
+	}
 }
 
 // Len returns amount of mapped IP addresses.
@@ -38,58 +209,413 @@ func (h *Hosts) Len() int {
 	return len(h.ipToAlias)
 }
 
-// GetAlias returns all aliases associated with specified IP address.
+// GetAlias returns all aliases associated with specified IP address (in order of addition with `PreserveOrder`
+// option).
 func (h *Hosts) GetAlias(ip netip.Addr) []string {
-	als := h.ipToAlias[ip]
-	res := make([]string, 0, len(als))
+	ip = h.normalizeIP(ip)
+	return h.GetAliasInto(ip, make([]string, 0, len(h.ipToAlias[ip])))
+}
+
+// GetAliasInto appends all aliases associated with specified IP address to provided slice and returns the extended
+// slice, which may share backing array with `dst`. Reusing the slice avoids allocation on every reverse lookup.
+func (h *Hosts) GetAliasInto(ip netip.Addr, dst []string) []string {
+	if h.opts.PreserveOrder {
+		return append(dst, h.order[h.normalizeIP(ip)]...)
+	}
+	for a := range h.ipToAlias[h.normalizeIP(ip)] {
+		dst = append(dst, a)
+	}
+	return dst
+}
+
+// AliasSet returns copy of the set of aliases associated with specified IP address.
+func (h *Hosts) AliasSet(ip netip.Addr) map[string]struct{} {
+	als := h.ipToAlias[h.normalizeIP(ip)]
+	res := make(map[string]struct{}, len(als))
 	for a := range als {
-		res = append(res, a)
+		res[a] = struct{}{}
+	}
+	return res
+}
+
+// CanonicalName returns canonical name of specified IP address or empty string if unknown. Requires `TrackCanonical`
+// option.
+func (h *Hosts) CanonicalName(ip netip.Addr) string {
+	return h.canonical[h.normalizeIP(ip)]
+}
+
+// SinkedAliases returns sorted aliases associated with specified sink IP address (like `0.0.0.0` or `127.0.0.1`).
+func (h *Hosts) SinkedAliases(sink netip.Addr) []string {
+	return h.sortedAliases(sink)
+}
+
+// SinkCount returns amount of aliases associated with specified sink IP address.
+func (h *Hosts) SinkCount(sink netip.Addr) int {
+	return len(h.ipToAlias[h.normalizeIP(sink)])
+}
+
+// DominantIP returns IP address with the most aliases and their amount. Zero address and 0 are returned for empty
+// table. Single IP holding vast majority of aliases indicates sink-style block list.
+func (h *Hosts) DominantIP() (netip.Addr, int) {
+	return h.dominantIP(false)
+}
+
+// Summary returns human readable summary of the table like
+// `42 IPs, 1337 hostnames, 3 multi-IP aliases, dominant sink 0.0.0.0 (1200)`. Sink part is omitted when no loopback
+// or unspecified address is mapped.
+func (h *Hosts) Summary() string {
+	multi := 0
+	for _, ips := range h.aliasToIp {
+		if len(ips) > 1 {
+			multi++
+		}
+	}
+	res := fmt.Sprintf("%d IPs, %d hostnames, %d multi-IP aliases", len(h.ipToAlias), len(h.aliasToIp), multi)
+	if sink, count := h.dominantIP(true); count > 0 {
+		res += fmt.Sprintf(", dominant sink %s (%d)", h.ipString(sink), count)
+	}
+	return res
+}
+
+// dominantIP returns IP address (optionally only unspecified or loopback one) with the most aliases preferring lower
+// addresses on ties.
+func (h *Hosts) dominantIP(onlySinks bool) (netip.Addr, int) {
+	var dominant netip.Addr
+	count := 0
+	for ip, aliases := range h.ipToAlias {
+		if onlySinks && !ip.IsUnspecified() && !ip.IsLoopback() {
+			continue
+		}
+		if len(aliases) > count || (len(aliases) == count && ip.Less(dominant)) {
+			dominant, count = ip, len(aliases)
+		}
 	}
+	return dominant, count
+}
+
+func (h *Hosts) sortedAliases(ip netip.Addr) []string {
+	res := h.GetAlias(ip)
+	sort.Strings(res)
 	return res
 }
 
-// GetIP returns all IP addresses associated with specified alias.
+// GetIP returns all IP addresses associated with specified alias (following `AddAlias` indirections and falling back
+// to wildcards added with `AddWildcard`).
 func (h *Hosts) GetIP(alias string) []netip.Addr {
-	ips := h.aliasToIp[alias]
-	res := make([]netip.Addr, 0, len(ips))
+	return h.GetIPInto(alias, make([]netip.Addr, 0, len(h.aliasToIp[alias])))
+}
+
+// GetIPInto appends all IP addresses associated with specified alias to provided slice and returns the extended
+// slice, which may share backing array with `dst`. Reusing the slice avoids allocation on every lookup.
+func (h *Hosts) GetIPInto(alias string, dst []netip.Addr) []netip.Addr {
+	ips, okIps := h.aliasToIp[h.resolveAlias(alias)]
+	if !okIps {
+		return h.wildcardIPInto(alias, dst)
+	}
 	for ip := range ips {
-		res = append(res, ip)
+		dst = append(dst, ip)
+	}
+	return dst
+}
+
+// Entries returns all entries sorted by IP address with sorted aliases.
+func (h *Hosts) Entries() []Entry {
+	return h.entries(h.sortedIPs())
+}
+
+// EntriesInOrder returns all entries in the order their IP addresses were first read or added (aliases are sorted).
+// Unlike `Entries` it reflects the layout of the source. Insertion order costs one additional map entry per IP.
+func (h *Hosts) EntriesInOrder() []Entry {
+	ips := make([]netip.Addr, 0, len(h.ipToAlias))
+	for ip := range h.ipToAlias {
+		ips = append(ips, ip)
+	}
+	sort.Slice(ips, func(i, j int) bool { return h.seq[ips[i]] < h.seq[ips[j]] })
+	return h.entries(ips)
+}
+
+func (h *Hosts) entries(ips []netip.Addr) []Entry {
+	res := make([]Entry, 0, len(ips))
+	for _, ip := range ips {
+		res = append(res, Entry{IP: ip, Aliases: h.sortedAliases(ip)})
+	}
+	return res
+}
+
+// Walk calls provided function for every IP address in ascending order with its sorted aliases and stops at the
+// first error returned, which is then returned by `Walk`.
+func (h *Hosts) Walk(fn func(ip netip.Addr, aliases []string) error) error {
+	for _, ip := range h.sortedIPs() {
+		if errFn := fn(ip, h.sortedAliases(ip)); errFn != nil {
+			return errFn
+		}
+	}
+	return nil
+}
+
+// Mappings returns all IP:Host pairs sorted by IP address and alias. Alias mapped to multiple IPs appears in multiple
+// pairs.
+func (h *Hosts) Mappings() []Mapping {
+	res := make([]Mapping, 0, len(h.aliasToIp))
+	for _, ip := range h.sortedIPs() {
+		for _, a := range h.sortedAliases(ip) {
+			res = append(res, Mapping{IP: ip, Alias: a})
+		}
+	}
+	return res
+}
+
+// ContainsMapping reports whether specified IP:Host mapping is present.
+func (h *Hosts) ContainsMapping(ip netip.Addr, alias string) bool {
+	_, okA := h.ipToAlias[h.normalizeIP(ip)][alias]
+	return okA
+}
+
+// Equal reports whether both `Hosts` instances hold exactly the same mappings (including wildcards).
+func (h *Hosts) Equal(other *Hosts) bool {
+	if len(h.ipToAlias) != len(other.ipToAlias) || len(h.aliasToIp) != len(other.aliasToIp) {
+		return false
+	}
+	if !equalWildcards(h.wildcards, other.wildcards) {
+		return false
+	}
+	for ip, aliases := range h.ipToAlias {
+		otherAliases := other.ipToAlias[ip]
+		if len(aliases) != len(otherAliases) {
+			return false
+		}
+		for a := range aliases {
+			if _, okA := otherAliases[a]; !okA {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Siblings returns all other aliases sharing at least one IP address with specified alias.
+func (h *Hosts) Siblings(alias string) []string {
+	set := make(strSet)
+	for ip := range h.aliasToIp[alias] {
+		for a := range h.ipToAlias[ip] {
+			if a != alias {
+				set[a] = struct{}{}
+			}
+		}
+	}
+
+	res := make([]string, 0, len(set))
+	for a := range set {
+		res = append(res, a)
 	}
 	return res
 }
 
 // Add adds IP:[]Host mapping skipping invalid IPs and hosts aliases.
 func (h *Hosts) Add(ip netip.Addr, alias ...string) {
+	h.add(ip, alias, "", nil, nil)
+}
+
+// AddChecked works like `Add` but returns accepted aliases (as stored, after `Transform`) and rejected ones. All
+// aliases are rejected for invalid (or not accepted) IP address. Aliases dropped by `Transform` are in neither.
+func (h *Hosts) AddChecked(ip netip.Addr, alias ...string) (accepted, rejected []string) {
+	if !ip.IsValid() || !h.acceptIP(h.normalizeIP(ip)) {
+		return nil, append(rejected, alias...)
+	}
+	h.add(ip, alias, "", func(a string) {
+		accepted = append(accepted, a)
+	}, func(a string) {
+		rejected = append(rejected, a)
+	})
+	return accepted, rejected
+}
+
+// AddWithTTL works like `Add` but also stores TTL of every added mapping for resolver integrations. TTLs are not
+// written to hosts file as the format cannot express them.
+func (h *Hosts) AddWithTTL(ttl time.Duration, ip netip.Addr, alias ...string) {
+	ip = h.normalizeIP(ip)
+	h.add(ip, alias, "", func(a string) {
+		h.ttl[mapping{ip, a}] = ttl
+	}, nil)
+}
+
+// TTL returns TTL of IP:Host mapping added with `AddWithTTL`.
+func (h *Hosts) TTL(ip netip.Addr, alias string) (time.Duration, bool) {
+	ttl, okTTL := h.ttl[mapping{h.normalizeIP(ip), alias}]
+	return ttl, okTTL
+}
+
+// add adds IP:[]Host mapping remembering original IP text (if provided) for newly added addresses. Optional accept
+// and reject callbacks are called for every valid and invalid alias respectively.
+func (h *Hosts) add(ip netip.Addr, alias []string, ipText string, accept, reject func(alias string)) {
 	if !ip.IsValid() || len(alias) == 0 {
 		return
 	}
+	if norm := h.normalizeIP(ip); norm != ip {
+		ip, ipText = norm, ""
+	}
+	if !h.acceptIP(ip) {
+		return
+	}
 	if _, okIp := h.ipToAlias[ip]; !okIp {
-		h.ipToAlias[ip] = make(strSet, len(alias))
+		h.newIP(ip, len(alias))
+		if h.opts.PreserveIPText && ipText != "" {
+			h.ipText[ip] = ipText
+		}
+		if addr, _, _ := strings.Cut(ipText, "%"); h.opts.PreserveIPCase && ip.Is6() && addr != strings.ToLower(addr) {
+			h.upperIP[ip] = struct{}{}
+		}
+	}
+	var ts time.Time
+	if h.opts.TrackTouched {
+		ts = now()
 	}
 
 	for _, a := range alias {
-		if !rgxValidAlias.MatchString(a) {
+		if h.opts.AliasSeparators != "" && strings.ContainsAny(a, h.opts.AliasSeparators) {
+			for _, part := range strings.FieldsFunc(a, h.isAliasSeparator) {
+				h.addAlias(ip, part, ipText, ts, accept, reject)
+			}
 			continue
 		}
-		h.ipToAlias[ip][a] = struct{}{}
+		h.addAlias(ip, a, ipText, ts, accept, reject)
+	}
+
+	if len(h.ipToAlias[ip]) == 0 {
+		h.dropIP(ip)
+	}
+}
 
-		if _, okA := h.aliasToIp[a]; !okA {
-			h.aliasToIp[a] = make(ipSet, 1)
+// addAlias adds single IP:Host mapping for IP address already present in IP-to-Host map.
+func (h *Hosts) addAlias(ip netip.Addr, alias, ipText string, ts time.Time, accept, reject func(alias string)) {
+	if h.opts.TrimAliasSpace {
+		alias = strings.TrimSpace(alias)
+	}
+	if h.opts.TrimTrailingDot {
+		alias = strings.TrimSuffix(alias, ".")
+	}
+	if h.opts.Transform != nil {
+		if alias = h.opts.Transform(alias); alias == "" {
+			return
+		}
+	}
+	if !h.validAlias(alias) {
+		if reject != nil {
+			reject(alias)
+		}
+		return
+	}
+	h.storeAlias(ip, alias, ipText, ts, accept)
+	if h.opts.ExpandWWW {
+		if variant := wwwVariant(alias); variant != "" && h.validAlias(variant) {
+			h.storeAlias(ip, variant, ipText, ts, accept)
 		}
-		h.aliasToIp[a][ip] = struct{}{}
 	}
+}
+
+// storeAlias stores single valid IP:Host mapping with its metadata.
+func (h *Hosts) storeAlias(ip netip.Addr, alias, ipText string, ts time.Time, accept func(alias string)) {
+	if _, okA := h.ipToAlias[ip][alias]; !okA && ipText == "" {
+		delete(h.ipText, ip)
+	}
+	h.link(ip, alias)
 
+	if h.opts.TrackTouched {
+		h.touched[mapping{ip, alias}] = ts
+	}
+	if _, okCanon := h.canonical[ip]; !okCanon && h.opts.TrackCanonical {
+		h.canonical[ip] = alias
+	}
+	if accept != nil {
+		accept(alias)
+	}
+}
+
+// wwwVariant returns alias with `www.` prefix added or removed. Empty string is returned for aliases without a dot
+// (in the bare domain).
+func wwwVariant(alias string) string {
+	if strings.HasPrefix(alias, "www.") {
+		if bare := alias[len("www."):]; strings.Contains(bare, ".") {
+			return bare
+		}
+		return ""
+	}
+	if strings.Contains(alias, ".") {
+		return "www." + alias
+	}
+	return ""
+}
+
+func (h *Hosts) isAliasSeparator(r rune) bool {
+	return strings.ContainsRune(h.opts.AliasSeparators, r)
+}
+
+// acceptIP checks whether IP address can be stored.
+func (h *Hosts) acceptIP(ip netip.Addr) bool {
+	switch {
+	case h.opts.Family == FamilyV4 && !ip.Is4(), h.opts.Family == FamilyV6 && !ip.Is6():
+		return false
+	}
+	return !h.opts.RequireSink || ip.IsLoopback() || ip.IsUnspecified()
+}
+
+// validAlias checks whether alias can be stored.
+func (h *Hosts) validAlias(alias string) bool {
+	return h.validateAlias(alias) == nil
+}
+
+// validateAlias validates alias with rules relaxed by options.
+func (h *Hosts) validateAlias(alias string) error {
+	return validateAlias(alias, aliasRules{underscore: h.opts.AllowUnderscore, leadingDigit: h.opts.AllowLeadingDigit})
+}
+
+// unlink removes single IP:Host mapping from both maps dropping emptied sets.
+func (h *Hosts) unlink(ip netip.Addr, alias string) {
+	if _, okA := h.ipToAlias[ip][alias]; okA {
+		// aliases changed, so original IP text is not written anymore
+		delete(h.ipText, ip)
+		if h.opts.PreserveOrder {
+			h.order[ip] = removeStr(h.order[ip], alias)
+			if len(h.order[ip]) == 0 {
+				delete(h.order, ip)
+			}
+		}
+	}
+	delete(h.ipToAlias[ip], alias)
 	if len(h.ipToAlias[ip]) == 0 {
-		delete(h.ipToAlias, ip)
+		h.dropIP(ip)
+	}
+
+	delete(h.aliasToIp[alias], ip)
+	if len(h.aliasToIp[alias]) == 0 {
+		delete(h.aliasToIp, alias)
+	}
+
+	delete(h.touched, mapping{ip, alias})
+	delete(h.priority, mapping{ip, alias})
+	delete(h.ttl, mapping{ip, alias})
+	delete(h.tags, mapping{ip, alias})
+	delete(h.comments, mapping{ip, alias})
+	if h.canonical[ip] == alias {
+		delete(h.canonical, ip)
 	}
 }
 
-// DelByIP removes all aliases associated with specified IP address.
+// DelByIP removes all aliases (and wildcards) associated with specified IP address. Aliases mapped to other IP
+// addresses too stay mapped to them.
 func (h *Hosts) DelByIP(ip netip.Addr) {
+	ip = h.normalizeIP(ip)
 	for a := range h.ipToAlias[ip] {
-		delete(h.aliasToIp, a)
+		h.unlink(ip, a)
 	}
-	delete(h.ipToAlias, ip)
+	h.delWildcards(ip)
+}
+
+// DropSink removes all aliases associated with specified sink IP address (like `0.0.0.0`) and returns their amount.
+func (h *Hosts) DropSink(sink netip.Addr) int {
+	count := h.SinkCount(sink)
+	h.DelByIP(sink)
+	return count
 }
 
 // DelByAlias removes all IP addresses (and their aliases) associated with specified alias.
@@ -97,66 +623,388 @@ func (h *Hosts) DelByAlias(alias string) {
 	for ip := range h.aliasToIp[alias] {
 		h.DelByIP(ip)
 	}
+	delete(h.cnames, alias)
+}
+
+// CheckConsistency verifies that IP-to-Host and Host-to-IP mappings mirror each other and hold no empty sets.
+// Returns error describing the first inconsistency found. Meant for debugging and tests.
+func (h *Hosts) CheckConsistency() error {
+	count := 0
+	for ip, aliases := range h.ipToAlias {
+		if len(aliases) == 0 {
+			return fmt.Errorf("empty alias set of %s", ip)
+		}
+		for a := range aliases {
+			if _, okIp := h.aliasToIp[a][ip]; !okIp {
+				return fmt.Errorf("mapping %s -> %s has no reverse mapping", ip, a)
+			}
+		}
+		count += len(aliases)
+	}
+	for a, ips := range h.aliasToIp {
+		if len(ips) == 0 {
+			return fmt.Errorf("empty IP set of %s", a)
+		}
+		for ip := range ips {
+			if _, okA := h.ipToAlias[ip][a]; !okA {
+				return fmt.Errorf("reverse mapping %s -> %s has no mapping", a, ip)
+			}
+		}
+		count -= len(ips)
+	}
+	if count != 0 {
+		return fmt.Errorf("maps hold different amount of mappings")
+	}
+	if h.opts.SortedIndex && len(h.index) != len(h.ipToAlias) {
+		return fmt.Errorf("sorted index holds %d of %d IP addresses", len(h.index), len(h.ipToAlias))
+	}
+	return nil
+}
+
+// Reindex rebuilds Host-to-IP mappings from IP-to-Host ones discarding any stale reverse entries.
+func (h *Hosts) Reindex() {
+	h.aliasToIp = make(map[string]ipSet, len(h.aliasToIp))
+	for ip, aliases := range h.ipToAlias {
+		if len(aliases) == 0 {
+			delete(h.ipToAlias, ip)
+			continue
+		}
+		if _, okSeq := h.seq[ip]; !okSeq {
+			h.seq[ip] = h.nextSeq
+			h.nextSeq++
+		}
+		for a := range aliases {
+			if _, okA := h.aliasToIp[a]; !okA {
+				h.aliasToIp[a] = make(ipSet, 1)
+			}
+			h.aliasToIp[a][ip] = struct{}{}
+		}
+	}
+	if h.opts.SortedIndex {
+		h.index = h.scanIPs()
+	}
+}
+
+// Prune removes all mappings not added (or read) since specified time and returns amount of removed mappings.
+// Requires `TrackTouched` option.
+func (h *Hosts) Prune(olderThan time.Time) int {
+	count := 0
+	for m, ts := range h.touched {
+		if ts.Before(olderThan) {
+			h.unlink(m.ip, m.alias)
+			count++
+		}
+	}
+	return count
+}
+
+// Priority returns priority of IP:Host mapping read with `ReadWithPriority` or 0 if not set.
+func (h *Hosts) Priority(ip netip.Addr, alias string) int {
+	return h.priority[mapping{h.normalizeIP(ip), alias}]
 }
 
 // Read appends hosts read from file using provided `io.Reader`.
 func (h *Hosts) Read(reader io.Reader) error {
-	bufRd := bufio.NewReader(reader)
+	return h.read(reader, h.newReadState(false))
+}
 
-	for {
-		line, errRead := bufRd.ReadString('\n')
-		if errRead != nil {
-			if errRead == io.EOF {
-				break
+// ReadWithPriority works like `Read` but treats trailing all-numeric token of a line as the priority of all aliases
+// from this line (e.g. `127.0.0.1 foo bar 10`).
+func (h *Hosts) ReadWithPriority(reader io.Reader) error {
+	return h.read(reader, h.newReadState(true))
+}
+
+// ReaderError is returned by `ReadAll` when reading from one of the readers failed.
+type ReaderError struct {
+	Index int
+	Err   error
+}
+
+func (e *ReaderError) Error() string {
+	return fmt.Sprintf("reader %d: %v", e.Index, e.Err)
+}
+
+func (e *ReaderError) Unwrap() error {
+	return e.Err
+}
+
+// ReadAll appends hosts read from all provided readers in order stopping at the first failing one (unless
+// `ContinueOnReaderError` option is set).
+func (h *Hosts) ReadAll(readers ...io.Reader) error {
+	var errs ReadErrors
+	for i, reader := range readers {
+		if errRead := h.read(reader, h.newReadState(false)); errRead != nil {
+			if !h.opts.ContinueOnReaderError {
+				return &ReaderError{Index: i, Err: errRead}
 			}
-			return errRead
+			errs = append(errs, &ReaderError{Index: i, Err: errRead})
 		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
 
-		// skip comments
-		if idx := strings.IndexAny(line, `#;`); idx > -1 {
-			line = line[0:idx]
+// ReadReplaceIfChanged reads hosts file using provided `io.Reader` into new table and replaces all mappings with it
+// only if mappings differ (regardless of lines order). Returns whether table was replaced. Table is left untouched on
+// error. Wildcards, alias indirections and metadata (like TTLs or tags) of mappings still present are kept.
+func (h *Hosts) ReadReplaceIfChanged(reader io.Reader) (bool, error) {
+	res := newHosts(len(h.ipToAlias), h.opts)
+	if errRead := res.Read(reader); errRead != nil {
+		return false, errRead
+	}
+	// wildcards and alias indirections are never read, so they are kept
+	res.wildcards, res.cnames = h.wildcards, h.cnames
+	if res.Equal(h) {
+		return false, nil
+	}
+	res.keepMetadata(h)
+	*h = res
+	return true, nil
+}
+
+// keepMetadata copies metadata of mappings still present in this table from replaced table, unless it was read again.
+func (h *Hosts) keepMetadata(old *Hosts) {
+	present := func(m mapping) bool {
+		_, okA := h.ipToAlias[m.ip][m.alias]
+		return okA
+	}
+	for m, ts := range old.touched {
+		if _, okTs := h.touched[m]; !okTs && present(m) {
+			h.touched[m] = ts
+		}
+	}
+	for m, prio := range old.priority {
+		if _, okPrio := h.priority[m]; !okPrio && present(m) {
+			h.priority[m] = prio
+		}
+	}
+	for m, ttl := range old.ttl {
+		if _, okTTL := h.ttl[m]; !okTTL && present(m) {
+			h.ttl[m] = ttl
 		}
+	}
+	for m, tags := range old.tags {
+		if present(m) {
+			for t := range tags {
+				h.tag(m, t)
+			}
+		}
+	}
+	for m, comments := range old.comments {
+		if _, okComments := h.comments[m]; !okComments && present(m) {
+			h.comments[m] = comments
+		}
+	}
+}
+
+func (h *Hosts) read(reader io.Reader, st *readState) error {
+	bufRd := bufio.NewReader(reader)
 
-		if matchHosts := rgxHostsFileLine.FindAllString(line, -1); len(matchHosts) > 1 {
-			ip, errParse := netip.ParseAddr(matchHosts[0])
-			if errParse != nil {
-				continue
+	// skip UTF-8 BOM
+	if bom, _ := bufRd.Peek(len(utf8BOM)); string(bom) == utf8BOM {
+		bufRd.Discard(len(utf8BOM))
+		h.bom = h.bom || (h.opts.Lossless && len(h.lines) == 0)
+	}
+
+	for lineNum := 1; ; lineNum++ {
+		raw, tooLong, errRead := readRawLine(bufRd, h.opts.MaxLineBytes)
+		if errRead != nil && errRead != io.EOF {
+			return errRead
+		}
+		if st.stats != nil && (tooLong || raw != "") {
+			st.stats.Lines++
+		}
+		if tooLong {
+			h.skipped(st, lineNum, "", ReasonLineTooLong, nil)
+		} else if raw != "" {
+			ip, accepted := h.readLine(st, lineNum, raw)
+			if h.opts.Lossless {
+				h.lines = append(h.lines, srcLine{raw: raw, ip: ip, aliases: accepted})
 			}
-			h.Add(ip, matchHosts[1:]...)
+		}
+		if errRead == io.EOF {
+			break
 		}
 	}
 
-	return nil
+	return st.err()
+}
+
+// readRawLine reads single line including line ending. Content of line exceeding max bytes (if set) is discarded
+// without buffering it.
+func readRawLine(bufRd *bufio.Reader, max int) (string, bool, error) {
+	if max <= 0 {
+		line, errRead := bufRd.ReadString('\n')
+		return line, false, errRead
+	}
+
+	var line []byte
+	tooLong := false
+	for {
+		chunk, errRead := bufRd.ReadSlice('\n')
+		if !tooLong {
+			if len(line)+len(chunk) > max {
+				line, tooLong = nil, true
+			} else {
+				line = append(line, chunk...)
+			}
+		}
+		if errRead != bufio.ErrBufferFull {
+			return string(line), tooLong, errRead
+		}
+	}
 }
 
-// Write writes all mappings from `Hosts` instance to hosts file using provided `io.Writer`.
-func (h *Hosts) Write(writer io.Writer) error {
-	bufWr := bufio.NewWriter(writer)
+// readLine parses single line adding its mappings. Returns IP address of the line and accepted aliases (collected
+// only in lossless mode).
+func (h *Hosts) readLine(st *readState, lineNum int, raw string) (netip.Addr, []string) {
+	var comments []string
+	if h.opts.KeepComments {
+		if h.readComment(st, raw) {
+			return netip.Addr{}, nil
+		}
+		comments, st.comments = st.comments, nil
+	}
+	line := h.stripComment(raw)
+
+	matchHosts := rgxHostsFileLine.FindAllString(line, -1)
+	if len(matchHosts) == 0 {
+		return netip.Addr{}, nil
+	}
+	ip, errParse := ValidateIP(matchHosts[0])
+	if errParse != nil {
+		h.skipped(st, lineNum, raw, ReasonInvalidIP, errParse)
+		return netip.Addr{}, nil
+	}
+	if len(matchHosts) == 1 {
+		h.skipped(st, lineNum, raw, ReasonIPOnly, nil)
+		return netip.Addr{}, nil
+	}
 
-	for ip, aliases := range h.ipToAlias {
-		addr := ip.String()
-		lineLen := len(addr)
-		aliasCount := 0
+	ipText := matchHosts[0]
+	if norm := h.normalizeIP(ip); norm != ip {
+		ip, ipText = norm, ""
+	}
+	if !h.acceptIP(ip) {
+		h.skipped(st, lineNum, raw, ReasonRejectedIP, nil)
+		return netip.Addr{}, nil
+	}
 
-		bufWr.WriteString(addr)
-		for alias := range aliases {
-			if (aliasCount > 0 && aliasCount%maxAliasesPerLine == 0) || lineLen+len(alias)+1 > maxLineLength {
-				bufWr.WriteString("\n")
-				bufWr.WriteString(addr)
-				lineLen = len(addr)
+	prio, withPrio := 0, false
+	if last := matchHosts[len(matchHosts)-1]; st.withPriority && len(matchHosts) > 2 && isDigits(last) {
+		if p, errPrio := strconv.Atoi(last); errPrio == nil {
+			matchHosts = matchHosts[:len(matchHosts)-1]
+			prio, withPrio = p, true
+		}
+	}
+
+	var accepted []string
+	var first string
+	var accept, reject func(string)
+	if withPrio || h.opts.Lossless || h.opts.Strict || st.tag != "" || len(comments) > 0 {
+		accept = func(a string) {
+			if first == "" {
+				first = a
+			}
+			if withPrio {
+				h.priority[mapping{ip, a}] = prio
+			}
+			if h.opts.Lossless {
+				accepted = append(accepted, a)
+			}
+			if h.opts.Strict {
+				st.checkDuplicate(lineNum, ip, a)
 			}
-			bufWr.WriteString(" ")
-			bufWr.WriteString(alias)
+			if st.tag != "" {
+				h.tag(mapping{ip, a}, st.tag)
+			}
+		}
+	}
+	if h.opts.Logger != nil || h.opts.Strict || st.stats != nil {
+		reject = func(a string) { h.skipped(st, lineNum, raw, ReasonInvalidAlias, h.validateAlias(a)) }
+	}
+	h.add(ip, matchHosts[1:], ipText, accept, reject)
+	if first != "" && len(comments) > 0 {
+		h.attachComments(mapping{ip, first}, comments)
+	}
 
-			lineLen += len(alias) + 1 // space
-			aliasCount++
+	if len(accepted) == 0 {
+		return netip.Addr{}, nil
+	}
+	return ip, accepted
+}
+
+// stripComment removes comment (starting with `#` or `;`) from the line.
+func (h *Hosts) stripComment(line string) string {
+	if !h.opts.CommentAtLineStartOrAfterSpace {
+		if idx := strings.IndexAny(line, `#;`); idx > -1 {
+			return line[0:idx]
 		}
-		bufWr.WriteString("\n")
+		return line
 	}
+	for idx, c := range line {
+		if (c == '#' || c == ';') && (idx == 0 || line[idx-1] == ' ' || line[idx-1] == '\t') {
+			return line[0:idx]
+		}
+	}
+	return line
+}
 
-	return bufWr.Flush()
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return len(s) > 0
+}
+
+// skipped reports skipped line to logger (if set) and collects it as error in strict mode.
+func (h *Hosts) skipped(st *readState, lineNum int, line, reason string, cause error) {
+	line = strings.TrimRight(line, "\r\n")
+	st.stats.count(reason)
+	if h.opts.Logger != nil {
+		h.opts.Logger(lineNum, line, reason)
+	}
+	if h.opts.Strict {
+		st.errs = append(st.errs, &LineError{Line: lineNum, Text: line, Reason: reason, Err: cause})
+	}
+}
+
+// normalizeIP returns IP address in the form used as mapping key.
+func (h *Hosts) normalizeIP(ip netip.Addr) netip.Addr {
+	if h.opts.UnmapV4 {
+		return ip.Unmap()
+	}
+	return ip
+}
+
+func sortAddrs(ips []netip.Addr) {
+	sort.Slice(ips, func(i, j int) bool { return ips[i].Less(ips[j]) })
+}
+
+// ipString returns textual form of IP address used for writing.
+func (h *Hosts) ipString(ip netip.Addr) string {
+	if addr, okText := h.ipText[ip]; okText {
+		return addr
+	}
+	if _, okUpper := h.upperIP[ip]; okUpper {
+		return upperIPString(ip)
+	}
+	return ip.String()
+}
+
+// upperIPString returns canonical form of IPv6 address with upper case hex digits (zone is kept as is).
+func upperIPString(ip netip.Addr) string {
+	if zone := ip.Zone(); zone != "" {
+		return strings.ToUpper(ip.WithZone("").String()) + "%" + zone
+	}
+	return strings.ToUpper(ip.String())
 }
 
+// String returns deterministic hosts file content produced by `Write`.
 func (h *Hosts) String() string {
 	var buf bytes.Buffer
 	h.Write(&buf)
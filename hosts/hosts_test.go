@@ -2,6 +2,8 @@ package hosts
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/netip"
@@ -9,6 +11,8 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
 )
 
 const exampleInput1 = `
@@ -47,6 +51,7 @@ var (
 func testCommon(t *testing.T, h *Hosts) {
 	// no duplicated entries
 	equalStrArr(t, []string{"localhost", "the-same"}, h.GetAlias(ip_127_0_0_1))
+	equalStrArr(t, []string{"prev", "localhost", "the-same"}, h.GetAliasInto(ip_127_0_0_1, []string{"prev"}))
 	equalStrArr(t, []string{"127.0.0.1"}, ipArrStr(h.GetIP("localhost")))
 
 	// parsing tabs/spaces separators
@@ -54,10 +59,17 @@ func testCommon(t *testing.T, h *Hosts) {
 
 	// multiple IPs for one alias
 	equalStrArr(t, []string{"192.168.1.1", "192.168.1.2"}, ipArrStr(h.GetIP("tabs")))
+	buf := []netip.Addr{ip_127_0_0_1}
+	equalStrArr(t, []string{"127.0.0.1", "192.168.1.1", "192.168.1.2"}, ipArrStr(h.GetIPInto("tabs", buf)))
 
 	// no entries with IP only
 	equal(t, 0, len(h.GetAlias(ip_192_168_1_3)))
 
+	// aliases sharing IPs
+	equal(t, []string{"the-same"}, h.Siblings("localhost"))
+	equalStrArr(t, []string{"spaces"}, h.Siblings("tabs"))
+	equal(t, 0, len(h.Siblings("good321")))
+
 	// only valid aliases
 	equalStrArr(t, []string{"good321"}, h.GetAlias(ip_172_16_0_1))
 	equal(t, 0, len(h.GetIP("1bad.org")))
@@ -76,10 +88,23 @@ func TestHostsManipulation(t *testing.T) {
 
 	// example has 4 valid entries
 	equal(t, 4, h.Len())
+	s := NewSized(100)
+	equal(t, 0, s.Len())
 
 	// common tests run
 	testCommon(t, &h)
 
+	// mappings checked without exposing internals
+	equal(t, true, h.ContainsMapping(ip_192_168_1_2, "tabs"))
+	equal(t, false, h.ContainsMapping(ip_192_168_1_2, "spaces"))
+	equal(t, false, h.ContainsMapping(ip_192_168_1_3, "tabs"))
+
+	// sinked aliases are sorted
+	equal(t, []string{"localhost", "the-same"}, h.SinkedAliases(ip_127_0_0_1))
+	equal(t, []string{}, h.SinkedAliases(netip.IPv4Unspecified()))
+	equal(t, 2, h.SinkCount(ip_127_0_0_1))
+	equal(t, 0, h.SinkCount(netip.IPv4Unspecified()))
+
 	// entry deleted by IP address
 	h.DelByIP(ip_127_0_0_1)
 	equal(t, 0, len(h.GetIP("localhost")))
@@ -93,6 +118,13 @@ func TestHostsManipulation(t *testing.T) {
 	equal(t, 0, len(h.GetAlias(ip_192_168_1_1)))
 	equal(t, 0, len(h.GetAlias(ip_192_168_1_2)))
 
+	// alias set is a copy
+	set := h.AliasSet(ip_172_16_0_1)
+	equal(t, map[string]struct{}{"good321": {}}, set)
+	delete(set, "good321")
+	equal(t, []string{"good321"}, h.GetAlias(ip_172_16_0_1))
+	equal(t, 0, len(h.AliasSet(ip_127_0_0_1)))
+
 	// only 1 entry left
 	equal(t, 1, h.Len())
 }
@@ -113,6 +145,19 @@ func TestReadWriteHostsFile(t *testing.T) {
 	// common tests run
 	testCommon(t, &h)
 
+	// the most aliases
+	dominant, count := h.DominantIP()
+	equal(t, ip_192_168_1_4, dominant)
+	equal(t, 50, count)
+	e := New()
+	dominant, count = e.DominantIP()
+	equal(t, netip.Addr{}, dominant)
+	equal(t, 0, count)
+
+	// summary
+	equal(t, "6 IPs, 58 hostnames, 1 multi-IP aliases, dominant sink 127.0.0.1 (2)", h.Summary())
+	equal(t, "0 IPs, 0 hostnames, 0 multi-IP aliases", e.Summary())
+
 	// long lines parsed
 	equalStrArr(t, []string{"192.168.1.4"}, ipArrStr(h.GetIP("d50")))
 	equal(t, 50, len(h.GetAlias(ip_192_168_1_4)))
@@ -143,6 +188,707 @@ func TestReadWriteHostsFile(t *testing.T) {
 	equal(t, 12, bytes.Count(b, []byte("\n")))
 }
 
+func TestReadLastLineWithoutNewline(t *testing.T) {
+	h := New()
+	equal(t, nil, h.Read(strings.NewReader("127.0.0.1 localhost\n192.168.1.1 router")))
+	equal(t, []string{"router"}, h.GetAlias(ip_192_168_1_1))
+	equal(t, 2, h.Len())
+
+	// comment without newline is still a comment
+	c := New()
+	equal(t, nil, c.Read(strings.NewReader("127.0.0.1 localhost\n# 192.168.1.1 router")))
+	equal(t, 1, c.Len())
+}
+
+func TestDelByIPSharedAlias(t *testing.T) {
+	h := New()
+	h.Add(ip_192_168_1_1, "shared", "first")
+	h.Add(ip_192_168_1_2, "shared", "second")
+
+	// alias stays mapped to the other IP address
+	h.DelByIP(ip_192_168_1_1)
+	equal(t, []string{"192.168.1.2"}, ipArrStr(h.GetIP("shared")))
+	equalStrArr(t, []string{"second", "shared"}, h.GetAlias(ip_192_168_1_2))
+	equal(t, 0, len(h.GetIP("first")))
+}
+
+func TestDropSink(t *testing.T) {
+	h := New()
+	h.Read(strings.NewReader(exampleInput1))
+
+	equal(t, 2, h.DropSink(ip_127_0_0_1))
+	equal(t, 0, h.DropSink(ip_127_0_0_1))
+	equal(t, 0, len(h.GetIP("localhost")))
+	equal(t, 0, len(h.GetAlias(ip_127_0_0_1)))
+	equal(t, 4, h.Len())
+}
+
+func TestDeterministicString(t *testing.T) {
+	h := New()
+	h.Add(ip_192_168_1_2, "tabs")
+	h.Add(ip_127_0_0_1, "the-same", "localhost")
+	h.Add(netip.IPv6Loopback(), "localhost")
+	h.Add(ip_192_168_1_1, "tabs", "spaces")
+	h.Add(ip_192_168_1_4, "d10", "d09", "d08", "d07", "d06", "d05", "d04", "d03", "d02", "d01")
+
+	expected := "127.0.0.1 localhost the-same\n" +
+		"192.168.1.1 spaces tabs\n" +
+		"192.168.1.2 tabs\n" +
+		"192.168.1.4 d01 d02 d03 d04 d05 d06 d07 d08 d09\n" +
+		"192.168.1.4 d10\n" +
+		"::1 localhost\n"
+
+	// stable across calls
+	for i := 0; i < 10; i++ {
+		equal(t, expected, h.String())
+	}
+}
+
+func TestEntriesOrder(t *testing.T) {
+	h := New()
+	h.Read(strings.NewReader(exampleInput2))
+	h.Add(ip_192_168_1_2, "tabs")
+	h.Add(ip_127_0_0_1, "localhost")
+	h.Add(ip_192_168_1_1, "bb", "aa")
+	h.DelByIP(ip_192_168_1_2)
+	h.Add(ip_192_168_1_2, "re-added")
+
+	// insertion order
+	equal(t, []Entry{
+		{IP: ip_172_16_0_1, Aliases: []string{"good321"}},
+		{IP: ip_127_0_0_1, Aliases: []string{"localhost"}},
+		{IP: ip_192_168_1_1, Aliases: []string{"aa", "bb"}},
+		{IP: ip_192_168_1_2, Aliases: []string{"re-added"}},
+	}, h.EntriesInOrder())
+
+	// flattened
+	equal(t, []Mapping{
+		{IP: ip_127_0_0_1, Alias: "localhost"},
+		{IP: ip_172_16_0_1, Alias: "good321"},
+		{IP: ip_192_168_1_1, Alias: "aa"},
+		{IP: ip_192_168_1_1, Alias: "bb"},
+		{IP: ip_192_168_1_2, Alias: "re-added"},
+	}, h.Mappings())
+
+	// sorted
+	equal(t, []Entry{
+		{IP: ip_127_0_0_1, Aliases: []string{"localhost"}},
+		{IP: ip_172_16_0_1, Aliases: []string{"good321"}},
+		{IP: ip_192_168_1_1, Aliases: []string{"aa", "bb"}},
+		{IP: ip_192_168_1_2, Aliases: []string{"re-added"}},
+	}, h.Entries())
+}
+
+func TestWalk(t *testing.T) {
+	h := New()
+	h.Add(ip_192_168_1_1, "bb", "aa")
+	h.Add(ip_127_0_0_1, "localhost")
+	h.Add(ip_192_168_1_2, "tabs")
+
+	var visited []Entry
+	errWalk := h.Walk(func(ip netip.Addr, aliases []string) error {
+		visited = append(visited, Entry{IP: ip, Aliases: aliases})
+		return nil
+	})
+	equal(t, nil, errWalk)
+	equal(t, h.Entries(), visited)
+
+	// stopped at first error
+	errStop := errors.New("stop")
+	visited = nil
+	errWalk = h.Walk(func(ip netip.Addr, aliases []string) error {
+		visited = append(visited, Entry{IP: ip, Aliases: aliases})
+		if ip == ip_192_168_1_1 {
+			return errStop
+		}
+		return nil
+	})
+	equal(t, errStop, errWalk)
+	equal(t, 2, len(visited))
+}
+
+func TestIPAliasesRejected(t *testing.T) {
+	h := New()
+	h.Add(ip_127_0_0_1, "127.0.0.1", "::1", "0x7f.1", "10.1", "localhost.127", "fe80.local")
+	equalStrArr(t, []string{"localhost.127", "fe80.local"}, h.GetAlias(ip_127_0_0_1))
+}
+
+func TestReindex(t *testing.T) {
+	h := New()
+	h.ipToAlias[ip_192_168_1_1] = strSet{"tabs": {}, "spaces": {}}
+	h.ipToAlias[ip_192_168_1_2] = strSet{"tabs": {}}
+	h.ipToAlias[ip_192_168_1_3] = strSet{}
+	h.aliasToIp["stale"] = ipSet{ip_127_0_0_1: {}}
+	equal(t, 0, len(h.GetIP("tabs")))
+
+	h.Reindex()
+	equalStrArr(t, []string{"192.168.1.1", "192.168.1.2"}, ipArrStr(h.GetIP("tabs")))
+	equal(t, []string{"192.168.1.1"}, ipArrStr(h.GetIP("spaces")))
+	equal(t, 0, len(h.GetIP("stale")))
+	equal(t, 2, h.Len())
+	equal(t, 2, len(h.EntriesInOrder()))
+}
+
+func TestReadAll(t *testing.T) {
+	h := New()
+	if errRead := h.ReadAll(strings.NewReader(exampleInput1), strings.NewReader(exampleInput2)); errRead != nil {
+		t.Fatal(errRead)
+	}
+	equal(t, 6, h.Len())
+	testCommon(t, &h)
+
+	// failing reader reported by index
+	errBroken := errors.New("broken")
+	s := New()
+	errRead := s.ReadAll(strings.NewReader(exampleInput2), iotest.ErrReader(errBroken), strings.NewReader(exampleInput1))
+
+	var errReader *ReaderError
+	equal(t, true, errors.As(errRead, &errReader))
+	equal(t, 1, errReader.Index)
+	equal(t, true, errors.Is(errRead, errBroken))
+	equal(t, "reader 1: broken", errRead.Error())
+	equal(t, 1, s.Len())
+
+	// all readers read with errors aggregated
+	c := NewWithOptions(Options{ContinueOnReaderError: true})
+	errRead = c.ReadAll(iotest.ErrReader(errBroken), strings.NewReader(exampleInput2),
+		iotest.ErrReader(io.ErrUnexpectedEOF), strings.NewReader(exampleInput1))
+
+	var readErrs ReadErrors
+	equal(t, true, errors.As(errRead, &readErrs))
+	equal(t, 2, len(readErrs))
+	equal(t, true, errors.Is(errRead, errBroken))
+	equal(t, true, errors.Is(errRead, io.ErrUnexpectedEOF))
+	equal(t, true, errors.As(readErrs[1], &errReader))
+	equal(t, 2, errReader.Index)
+	equal(t, 6, c.Len())
+}
+
+func TestReadReplaceIfChanged(t *testing.T) {
+	h := New()
+	h.Read(strings.NewReader(exampleInput1))
+
+	// reordered lines with the same mappings
+	changed, errRead := h.ReadReplaceIfChanged(strings.NewReader(h.String() + "192.168.1.1 tabs\n127.0.0.1 localhost\n"))
+	equal(t, nil, errRead)
+	equal(t, false, changed)
+
+	changed, errRead = h.ReadReplaceIfChanged(strings.NewReader("127.0.0.1 localhost\n"))
+	equal(t, nil, errRead)
+	equal(t, true, changed)
+	equal(t, "127.0.0.1 localhost\n", h.String())
+
+	// untouched on error
+	changed, errRead = h.ReadReplaceIfChanged(iotest.ErrReader(errors.New("broken")))
+	equal(t, false, changed)
+	equal(t, "broken", errRead.Error())
+	equal(t, "127.0.0.1 localhost\n", h.String())
+}
+
+func TestReadReplaceIfChangedKeepsState(t *testing.T) {
+	h := New()
+	h.ReadTagged(strings.NewReader("127.0.0.1 localhost db\n"), "local")
+	h.AddWithTTL(time.Minute, ip_192_168_1_1, "router")
+	equal(t, nil, h.AddAlias("database", "db"))
+
+	changed, errRead := h.ReadReplaceIfChanged(strings.NewReader("127.0.0.1 localhost db\n192.168.1.1 router\n" +
+		"192.168.1.2 printer\n"))
+	equal(t, nil, errRead)
+	equal(t, true, changed)
+
+	// state of mappings still present survives replacement
+	equal(t, []string{"127.0.0.1"}, ipArrStr(h.GetIP("database")))
+	ttl, okTTL := h.TTL(ip_192_168_1_1, "router")
+	equal(t, true, okTTL)
+	equal(t, time.Minute, ttl)
+	equal(t, []string{"local"}, h.Tags(ip_127_0_0_1, "db"))
+	equal(t, 0, len(h.Tags(ip_192_168_1_2, "printer")))
+
+	// state of removed mappings is dropped
+	changed, _ = h.ReadReplaceIfChanged(strings.NewReader("127.0.0.1 localhost db\n"))
+	equal(t, true, changed)
+	_, okTTL = h.TTL(ip_192_168_1_1, "router")
+	equal(t, false, okTTL)
+}
+
+func TestCanonicalName(t *testing.T) {
+	h := NewWithOptions(Options{TrackCanonical: true})
+	h.Read(strings.NewReader("127.0.0.1 localhost.localdomain localhost\n127.0.0.1 another\n" +
+		"192.168.1.1 1bad zhost ahost\n"))
+
+	equal(t, "localhost.localdomain", h.CanonicalName(ip_127_0_0_1))
+	equal(t, "zhost", h.CanonicalName(ip_192_168_1_1))
+	equal(t, "", h.CanonicalName(ip_192_168_1_2))
+
+	// canonical name written first
+	equal(t, "127.0.0.1 localhost.localdomain another localhost\n192.168.1.1 zhost ahost\n", h.String())
+
+	// not tracked by default
+	d := New()
+	d.Add(ip_192_168_1_1, "zhost", "ahost")
+	equal(t, "", d.CanonicalName(ip_192_168_1_1))
+	equal(t, "192.168.1.1 ahost zhost\n", d.String())
+}
+
+func TestAliasSeparators(t *testing.T) {
+	// tokens kept whole by default
+	d := New()
+	d.Add(ip_127_0_0_1, "a.com,b.com")
+	equal(t, 0, d.Len())
+
+	h := NewWithOptions(Options{AliasSeparators: ",|"})
+	h.Add(ip_127_0_0_1, "a.com,b.com")
+	h.Read(strings.NewReader("192.168.1.1 c.com|,d.com,1bad.com e.com\n"))
+	equalStrArr(t, []string{"a.com", "b.com"}, h.GetAlias(ip_127_0_0_1))
+	equalStrArr(t, []string{"c.com", "d.com", "e.com"}, h.GetAlias(ip_192_168_1_1))
+}
+
+func TestTransform(t *testing.T) {
+	h := NewWithOptions(Options{Transform: func(alias string) string {
+		if alias == "drop.me" {
+			return ""
+		}
+		return strings.TrimPrefix(strings.ToLower(alias), "www.")
+	}})
+	h.Add(ip_127_0_0_1, "WWW.Example.COM", "drop.me", "LocalHost")
+	h.Read(strings.NewReader("192.168.1.1 www.example.com drop.me\n"))
+
+	equalStrArr(t, []string{"example.com", "localhost"}, h.GetAlias(ip_127_0_0_1))
+	equalStrArr(t, []string{"example.com"}, h.GetAlias(ip_192_168_1_1))
+	equal(t, 0, len(h.GetIP("drop.me")))
+}
+
+func TestMaxLineBytes(t *testing.T) {
+	var logged []string
+	h := NewWithOptions(Options{MaxLineBytes: 32, Logger: func(lineNum int, line, reason string) {
+		logged = append(logged, fmt.Sprintf("%d:%s", lineNum, reason))
+	}})
+
+	long := "192.168.1.1 " + strings.Repeat("x", 8192)
+	if errRead := h.Read(strings.NewReader("127.0.0.1 localhost\n" + long + "\n192.168.1.2 tabs\n" + long)); errRead != nil {
+		t.Fatal(errRead)
+	}
+	equal(t, 2, h.Len())
+	equal(t, 0, len(h.GetAlias(ip_192_168_1_1)))
+	equal(t, []string{"2:line-too-long", "4:line-too-long"}, logged)
+}
+
+func TestRequireSink(t *testing.T) {
+	var logged []string
+	h := NewWithOptions(Options{RequireSink: true, Logger: func(lineNum int, line, reason string) {
+		logged = append(logged, fmt.Sprintf("%d:%s", lineNum, reason))
+	}})
+	h.Read(strings.NewReader("0.0.0.0 ads\n8.8.8.8 bank.com\n127.0.0.1 tracker\n:: ads\n"))
+	h.Add(ip_192_168_1_1, "router")
+
+	equal(t, 3, h.Len())
+	equal(t, 0, len(h.GetIP("bank.com")))
+	equal(t, 0, len(h.GetIP("router")))
+	equal(t, []string{"2:rejected-ip"}, logged)
+}
+
+func TestFamily(t *testing.T) {
+	const input = "127.0.0.1 localhost\n::1 localhost\n192.168.1.1 router\nfe80::1%eth0 router\n::ffff:10.0.0.1 mapped\n"
+
+	for family, expected := range map[Family]string{
+		FamilyBoth: "127.0.0.1 localhost\n192.168.1.1 router\n::1 localhost\n::ffff:10.0.0.1 mapped\nfe80::1%eth0 router\n",
+		FamilyV4:   "127.0.0.1 localhost\n192.168.1.1 router\n",
+		FamilyV6:   "::1 localhost\n::ffff:10.0.0.1 mapped\nfe80::1%eth0 router\n",
+	} {
+		var logged int
+		h := NewWithOptions(Options{Family: family, Logger: func(int, string, string) { logged++ }})
+		h.Read(strings.NewReader(input))
+		equal(t, expected, h.String())
+		equal(t, 5-strings.Count(expected, "\n"), logged)
+	}
+
+	// mapped addresses are IPv4 when unmapped
+	h := NewWithOptions(Options{Family: FamilyV4, UnmapV4: true})
+	h.Read(strings.NewReader(input))
+	h.Add(netip.IPv6Loopback(), "added")
+	equal(t, "10.0.0.1 mapped\n127.0.0.1 localhost\n192.168.1.1 router\n", h.String())
+}
+
+func TestAddChecked(t *testing.T) {
+	h := NewWithOptions(Options{Transform: strings.ToLower})
+	accepted, rejected := h.AddChecked(ip_172_16_0_1, "1bad.org", "totaly$%@wrong", "Good321", ".looked.ok", "Good321")
+	equal(t, []string{"good321", "good321"}, accepted)
+	equal(t, []string{"1bad.org", "totaly$%@wrong", ".looked.ok"}, rejected)
+	equal(t, []string{"good321"}, h.GetAlias(ip_172_16_0_1))
+
+	// invalid IP rejects everything
+	accepted, rejected = h.AddChecked(netip.Addr{}, "valid")
+	equal(t, 0, len(accepted))
+	equal(t, []string{"valid"}, rejected)
+	equal(t, 1, h.Len())
+}
+
+func TestAddWithTTL(t *testing.T) {
+	h := New()
+	h.AddWithTTL(time.Minute, ip_127_0_0_1, "localhost", "1bad")
+	h.Add(ip_127_0_0_1, "plain")
+
+	ttl, okTTL := h.TTL(ip_127_0_0_1, "localhost")
+	equal(t, time.Minute, ttl)
+	equal(t, true, okTTL)
+
+	_, okTTL = h.TTL(ip_127_0_0_1, "plain")
+	equal(t, false, okTTL)
+	_, okTTL = h.TTL(ip_127_0_0_1, "1bad")
+	equal(t, false, okTTL)
+
+	// not written
+	equal(t, "127.0.0.1 localhost plain\n", h.String())
+
+	// removed with mapping
+	h.DelByIP(ip_127_0_0_1)
+	_, okTTL = h.TTL(ip_127_0_0_1, "localhost")
+	equal(t, false, okTTL)
+}
+
+func TestReadTrailingWhitespace(t *testing.T) {
+	var logged []string
+	h := NewWithOptions(Options{Logger: func(lineNum int, line, reason string) {
+		logged = append(logged, reason)
+	}})
+	h.Read(strings.NewReader("127.0.0.1   localhost   \n192.168.1.1\ttabs\t \r\n  192.168.1.2 spaces \t"))
+
+	// no empty tokens produced
+	equal(t, []string{"localhost"}, h.GetAlias(ip_127_0_0_1))
+	equal(t, []string{"tabs"}, h.GetAlias(ip_192_168_1_1))
+	equal(t, []string{"spaces"}, h.GetAlias(ip_192_168_1_2))
+	equal(t, 0, len(logged))
+
+	// empty aliases never stored
+	h.Add(ip_192_168_1_3, "", " ", "\t")
+	equal(t, 0, len(h.GetAlias(ip_192_168_1_3)))
+	equal(t, 0, len(h.GetIP("")))
+	equal(t, 3, h.Len())
+}
+
+func TestReadUTF8BOM(t *testing.T) {
+	h := New()
+	if errRead := h.Read(strings.NewReader("\xEF\xBB\xBF127.0.0.1 localhost\n192.168.1.1 tabs\n")); errRead != nil {
+		t.Fatal(errRead)
+	}
+	equal(t, 2, h.Len())
+	equal(t, []string{"localhost"}, h.GetAlias(ip_127_0_0_1))
+}
+
+func TestPreserveIPText(t *testing.T) {
+	h := NewWithOptions(Options{PreserveIPText: true})
+	if errRead := h.Read(strings.NewReader("FE80:0:0:0:0:0:0:1 upper\n0:0:0:0:0:0:0:1 expanded\n")); errRead != nil {
+		t.Fatal(errRead)
+	}
+	out := h.String()
+
+	// original text is written back
+	equal(t, 1, strings.Count(out, "FE80:0:0:0:0:0:0:1 upper\n"))
+	equal(t, 1, strings.Count(out, "0:0:0:0:0:0:0:1 expanded\n"))
+
+	// changing aliases falls back to canonical form
+	h.Add(netip.IPv6Loopback(), "added")
+	out = h.String()
+	equal(t, 1, strings.Count(out, "FE80:0:0:0:0:0:0:1 upper\n"))
+	equal(t, 1, strings.Count(out, "0:0:0:0:0:0:0:1 ")) // only as suffix of FE80 address
+	equal(t, 1, strings.Count(out, "::1 "))
+
+	// so does removing aliases
+	clock := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+	r := NewWithOptions(Options{PreserveIPText: true, TrackTouched: true})
+	r.Read(strings.NewReader("0:0:0:0:0:0:0:1 aa bb\n"))
+	clock = clock.Add(time.Hour)
+	r.Add(netip.IPv6Loopback(), "aa")
+	equal(t, "0:0:0:0:0:0:0:1 aa bb\n", r.String())
+	equal(t, 1, r.Prune(clock))
+	equal(t, "::1 aa\n", r.String())
+
+	// canonical form is the default
+	d := New()
+	d.Read(strings.NewReader("FE80:0:0:0:0:0:0:1 upper\n"))
+	equal(t, "fe80::1 upper\n", d.String())
+}
+
+func TestCommentAtLineStartOrAfterSpace(t *testing.T) {
+	const input = "#comment\n127.0.0.1 foo #c\n127.0.0.2 foo#c\n127.0.0.3\tbar;c bar\t;c\n"
+
+	var reasons []string
+	h := NewWithOptions(Options{CommentAtLineStartOrAfterSpace: true, Logger: func(_ int, _, reason string) {
+		reasons = append(reasons, reason)
+	}})
+	h.Read(strings.NewReader(input))
+	equal(t, "127.0.0.1 foo\n127.0.0.3 bar\n", h.String())
+	equal(t, []string{ReasonInvalidAlias, ReasonInvalidAlias}, reasons)
+
+	// comment anywhere is the default
+	d := New()
+	d.Read(strings.NewReader(input))
+	equal(t, "127.0.0.1 foo\n127.0.0.2 foo\n127.0.0.3 bar\n", d.String())
+}
+
+func TestPreserveIPCase(t *testing.T) {
+	h := NewWithOptions(Options{PreserveIPCase: true})
+	h.Read(strings.NewReader("FE80:0:0:0:0:0:0:1 upper\nFe80::2%Eth0 mixed\n::ABCD lower\nfe80::3 kept\n" +
+		"fe80::4%Eth0 zone\n"))
+	h.Add(netip.MustParseAddr("fe80::1"), "added")
+	h.Add(netip.MustParseAddr("::abcd"), "added")
+	h.Add(netip.MustParseAddr("::1"), "new")
+
+	equal(t, "::1 new\n::ABCD added lower\nFE80::1 added upper\nFE80::2%Eth0 mixed\nfe80::3 kept\n"+
+		"fe80::4%Eth0 zone\n", h.String())
+
+	// casing is forgotten with the address
+	h.DelByIP(netip.MustParseAddr("::abcd"))
+	h.Add(netip.MustParseAddr("::abcd"), "again")
+	equal(t, 1, strings.Count(h.String(), "::abcd again\n"))
+
+	// canonical form is the default
+	d := New()
+	d.Read(strings.NewReader("FE80::1 upper\n"))
+	equal(t, "fe80::1 upper\n", d.String())
+}
+
+func TestPrune(t *testing.T) {
+	clock := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	h := NewWithOptions(Options{TrackTouched: true})
+	h.Add(ip_127_0_0_1, "localhost", "stale")
+	h.Add(ip_192_168_1_1, "tabs", "spaces")
+
+	// mark and sweep refresh
+	clock = clock.Add(time.Hour)
+	refresh := clock
+	h.Add(ip_127_0_0_1, "localhost")
+	h.Add(ip_192_168_1_2, "tabs")
+
+	equal(t, 3, h.Prune(refresh))
+	equal(t, []string{"localhost"}, h.GetAlias(ip_127_0_0_1))
+	equal(t, []string{"192.168.1.2"}, ipArrStr(h.GetIP("tabs")))
+	equal(t, 0, len(h.GetIP("spaces")))
+	equal(t, 2, h.Len())
+
+	// nothing left to prune
+	equal(t, 0, h.Prune(refresh))
+}
+
+func TestReadLogger(t *testing.T) {
+	var logged []string
+	h := NewWithOptions(Options{Logger: func(lineNum int, line, reason string) {
+		logged = append(logged, fmt.Sprintf("%d:%s:%s", lineNum, reason, line))
+	}})
+	if errRead := h.Read(strings.NewReader(exampleInput2)); errRead != nil {
+		t.Fatal(errRead)
+	}
+
+	badAliases := "172.16.0.1 1bad.org totaly$%@wrong .looked.ok this.is.bad.too. good321"
+	equal(t, []string{
+		"2:invalid-alias:" + badAliases,
+		"2:invalid-alias:" + badAliases,
+		"2:invalid-alias:" + badAliases,
+		"2:invalid-alias:" + badAliases,
+		"3:invalid-ip:010.0.10.1 tabs",
+		"4:invalid-ip:not-an-ip but-domain.ok",
+	}, logged)
+
+	// IP only lines
+	logged = nil
+	h.Read(strings.NewReader("192.168.1.3 # comment\n"))
+	equal(t, []string{"1:ip-only:192.168.1.3 # comment"}, logged)
+}
+
+func TestReadWithPriority(t *testing.T) {
+	const input = "127.0.0.1 foo bar 10\n127.0.0.1 baz\n192.168.1.1 qux 5\n192.168.1.2 123\n"
+
+	h := New()
+	if errRead := h.ReadWithPriority(strings.NewReader(input)); errRead != nil {
+		t.Fatal(errRead)
+	}
+	equal(t, 10, h.Priority(ip_127_0_0_1, "foo"))
+	equal(t, 10, h.Priority(ip_127_0_0_1, "bar"))
+	equal(t, 0, h.Priority(ip_127_0_0_1, "baz"))
+	equal(t, 5, h.Priority(ip_192_168_1_1, "qux"))
+
+	// single numeric token is not a priority
+	equal(t, 2, h.Len())
+
+	// priorities written back only with matching option
+	var buf bytes.Buffer
+	if errWrite := h.WriteWithOptions(&buf, WriteOptions{Priority: true}); errWrite != nil {
+		t.Fatal(errWrite)
+	}
+	equal(t, "127.0.0.1 baz\n127.0.0.1 bar foo 10\n192.168.1.1 qux 5\n", buf.String())
+	equal(t, "127.0.0.1 bar baz foo\n192.168.1.1 qux\n", h.String())
+
+	// standard read ignores priorities
+	s := New()
+	s.Read(strings.NewReader(input))
+	equal(t, 0, s.Priority(ip_127_0_0_1, "foo"))
+	equalStrArr(t, []string{"foo", "bar", "baz"}, s.GetAlias(ip_127_0_0_1))
+}
+
+func TestPriorityUnmapV4(t *testing.T) {
+	h := NewWithOptions(Options{UnmapV4: true})
+	h.ReadWithPriority(strings.NewReader("::ffff:192.168.1.1 foo 5\n"))
+	equal(t, 5, h.Priority(ip_192_168_1_1, "foo"))
+	equal(t, 5, h.Priority(netip.MustParseAddr("::ffff:192.168.1.1"), "foo"))
+}
+
+func TestUnmapV4(t *testing.T) {
+	mapped := netip.MustParseAddr("::ffff:192.168.1.1")
+
+	// kept as-is by default
+	d := New()
+	d.Add(mapped, "mapped")
+	d.Add(ip_192_168_1_1, "plain")
+	equal(t, 2, d.Len())
+
+	// both forms merged when normalized
+	h := NewWithOptions(Options{UnmapV4: true})
+	h.Add(mapped, "mapped")
+	h.Read(strings.NewReader("192.168.1.1 plain\n::ffff:192.168.1.1 read\n"))
+	equal(t, 1, h.Len())
+	equalStrArr(t, []string{"mapped", "plain", "read"}, h.GetAlias(ip_192_168_1_1))
+	equalStrArr(t, []string{"mapped", "plain", "read"}, h.GetAlias(mapped))
+	equal(t, map[string]struct{}{"mapped": {}, "plain": {}, "read": {}}, h.AliasSet(mapped))
+	equal(t, []string{"192.168.1.1"}, ipArrStr(h.GetIP("mapped")))
+	equal(t, "192.168.1.1 mapped plain read\n", h.String())
+
+	h.DelByIP(mapped)
+	equal(t, 0, h.Len())
+}
+
+func TestPreserveOrder(t *testing.T) {
+	h := NewWithOptions(Options{PreserveOrder: true})
+	h.Read(strings.NewReader("127.0.0.1 zhost localhost zhost\n127.0.0.1 another\n192.168.1.1 b.com a.com\n"))
+
+	equal(t, []string{"zhost", "localhost", "another"}, h.GetAlias(ip_127_0_0_1))
+	equal(t, "127.0.0.1 zhost localhost another\n192.168.1.1 b.com a.com\n", h.String())
+
+	// removed alias dropped from order, re-added one goes last
+	h.unlink(ip_127_0_0_1, "zhost")
+	h.Add(ip_127_0_0_1, "zhost")
+	equal(t, []string{"localhost", "another", "zhost"}, h.GetAlias(ip_127_0_0_1))
+
+	h.DelByIP(ip_192_168_1_1)
+	equal(t, 0, len(h.GetAlias(ip_192_168_1_1)))
+	equal(t, 1, len(h.order))
+}
+
+func TestExpandWWW(t *testing.T) {
+	h := NewWithOptions(Options{ExpandWWW: true})
+	accepted, _ := h.AddChecked(netip.IPv4Unspecified(), "example.com", "www.other.com", "www.www.third.com", "localhost", "www.com")
+	equal(t, []string{"example.com", "www.example.com", "www.other.com", "other.com", "www.www.third.com",
+		"www.third.com", "localhost", "www.com"}, accepted)
+	equal(t, "0.0.0.0 example.com localhost other.com www.com www.example.com www.other.com www.third.com "+
+		"www.www.third.com\n", h.String())
+
+	// default off
+	h = New()
+	h.Add(netip.IPv4Unspecified(), "example.com")
+	equal(t, []string{"example.com"}, h.GetAlias(netip.IPv4Unspecified()))
+}
+
+func TestTrimTrailingDot(t *testing.T) {
+	h := NewWithOptions(Options{TrimTrailingDot: true})
+	h.Read(strings.NewReader("192.168.1.1 fqdn.example.org. plain.example.org\n"))
+	h.Add(ip_192_168_1_1, "added.example.org.", "double..", ".")
+	equal(t, "192.168.1.1 added.example.org fqdn.example.org plain.example.org\n", h.String())
+
+	// rejected by default
+	h = New()
+	h.Add(ip_192_168_1_1, "fqdn.example.org.")
+	equal(t, 0, h.Len())
+}
+
+func TestTrimAliasSpace(t *testing.T) {
+	h := NewWithOptions(Options{TrimAliasSpace: true})
+	h.Read(strings.NewReader("192.168.1.1 \u00a0spaced.lan\u2003 plain\n"))
+	h.Add(ip_127_0_0_1, " localhost\t", "\u00a0")
+	equal(t, "127.0.0.1 localhost\n192.168.1.1 plain spaced.lan\n", h.String())
+
+	// rejected by default
+	h = New()
+	h.Read(strings.NewReader("192.168.1.1 \u00a0spaced.lan\n"))
+	equal(t, 0, h.Len())
+}
+
+func TestCheckConsistency(t *testing.T) {
+	h := New()
+	h.Read(strings.NewReader(exampleInput1))
+	equal(t, nil, h.CheckConsistency())
+
+	h.ipToAlias[ip_192_168_1_1]["manual"] = struct{}{}
+	equal(t, "mapping 192.168.1.1 -> manual has no reverse mapping", h.CheckConsistency().Error())
+	h.Reindex()
+	equal(t, nil, h.CheckConsistency())
+
+	h.aliasToIp["tabs"][ip_192_168_1_5] = struct{}{}
+	equal(t, "reverse mapping tabs -> 192.168.1.5 has no mapping", h.CheckConsistency().Error())
+	delete(h.aliasToIp["tabs"], ip_192_168_1_5)
+
+	h.aliasToIp["empty"] = ipSet{}
+	equal(t, "empty IP set of empty", h.CheckConsistency().Error())
+}
+
+func FuzzRead(f *testing.F) {
+	f.Add([]byte(exampleInput1))
+	f.Add([]byte(exampleInput2))
+	f.Add([]byte("127.0.0.1 localhost\r\n::1 localhost # comment\r\n"))
+	f.Add([]byte("\xEF\xBB\xBF0.0.0.0 ads.example.com;comment\n192.168.1.1"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		h := New()
+		h.Read(bytes.NewReader(data))
+		if errCheck := h.CheckConsistency(); errCheck != nil {
+			t.Fatal(errCheck)
+		}
+	})
+}
+
+func BenchmarkGetIP(b *testing.B) {
+	h := New()
+	h.Read(strings.NewReader(exampleInput1))
+
+	b.Run("GetIP", func(bb *testing.B) {
+		bb.ReportAllocs()
+		for i := 0; i < bb.N; i++ {
+			h.GetIP("tabs")
+		}
+	})
+
+	b.Run("GetIPInto", func(bb *testing.B) {
+		bb.ReportAllocs()
+		buf := make([]netip.Addr, 0, 8)
+		for i := 0; i < bb.N; i++ {
+			buf = h.GetIPInto("tabs", buf[:0])
+		}
+	})
+}
+
+func BenchmarkGetAlias(b *testing.B) {
+	h := New()
+	h.Read(strings.NewReader(exampleInput1))
+
+	b.Run("GetAlias", func(bb *testing.B) {
+		bb.ReportAllocs()
+		for i := 0; i < bb.N; i++ {
+			h.GetAlias(ip_192_168_1_1)
+		}
+	})
+
+	b.Run("GetAliasInto", func(bb *testing.B) {
+		bb.ReportAllocs()
+		buf := make([]string, 0, 8)
+		for i := 0; i < bb.N; i++ {
+			buf = h.GetAliasInto(ip_192_168_1_1, buf[:0])
+		}
+	})
+}
+
 func BenchmarkStevenBlackHosts(b *testing.B) {
 	resp, errResp := http.Get(benchHostListUrl)
 	if errResp != nil {
@@ -167,6 +913,25 @@ func BenchmarkStevenBlackHosts(b *testing.B) {
 	})
 	b.Logf("Parsed entries: %d", len(h.ipToAlias[netip.IPv4Unspecified()]))
 
+	b.Run("read-sized", func(bb *testing.B) {
+		for i := 0; i < bb.N; i++ {
+			s := NewSized(len(h.aliasToIp))
+			if errRead := s.Read(bytes.NewReader(list)); errRead != nil {
+				bb.Fatal(errRead)
+			}
+		}
+	})
+
+	data, _ := h.MarshalBinary()
+	b.Run("unmarshal", func(bb *testing.B) {
+		for i := 0; i < bb.N; i++ {
+			u := New()
+			if errUnmarshal := u.UnmarshalBinary(data); errUnmarshal != nil {
+				bb.Fatal(errUnmarshal)
+			}
+		}
+	})
+
 	b.Run("write", func(bb *testing.B) {
 		for i := 0; i < bb.N; i++ {
 			var buf bytes.Buffer
@@ -0,0 +1,39 @@
+package hosts
+
+import (
+	"net/netip"
+	"sort"
+)
+
+// newIP registers IP address not mapped yet.
+func (h *Hosts) newIP(ip netip.Addr, sizeHint int) {
+	h.ipToAlias[ip] = make(strSet, sizeHint)
+	h.seq[ip] = h.nextSeq
+	h.nextSeq++
+
+	if h.opts.SortedIndex {
+		idx := h.indexOf(ip)
+		h.index = append(h.index, netip.Addr{})
+		copy(h.index[idx+1:], h.index[idx:])
+		h.index[idx] = ip
+	}
+}
+
+// dropIP forgets IP address which has no aliases left.
+func (h *Hosts) dropIP(ip netip.Addr) {
+	delete(h.ipToAlias, ip)
+	delete(h.ipText, ip)
+	delete(h.upperIP, ip)
+	delete(h.seq, ip)
+
+	if h.opts.SortedIndex {
+		if idx := h.indexOf(ip); idx < len(h.index) && h.index[idx] == ip {
+			h.index = append(h.index[:idx], h.index[idx+1:]...)
+		}
+	}
+}
+
+// indexOf returns position of IP address in sorted index (or position where it would be inserted).
+func (h *Hosts) indexOf(ip netip.Addr) int {
+	return sort.Search(len(h.index), func(i int) bool { return !h.index[i].Less(ip) })
+}
@@ -0,0 +1,75 @@
+package hosts
+
+import (
+	"fmt"
+	"math/rand"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSortedIndex(t *testing.T) {
+	h := NewWithOptions(Options{SortedIndex: true, UnmapV4: true})
+	rnd := rand.New(rand.NewSource(1))
+
+	randIP := func() netip.Addr {
+		if rnd.Intn(4) == 0 {
+			return netip.AddrFrom16([16]byte{10: 0xff, 11: 0xff, 15: byte(rnd.Intn(16))})
+		}
+		return netip.AddrFrom4([4]byte{10, 0, byte(rnd.Intn(4)), byte(rnd.Intn(16))})
+	}
+	randAlias := func() string {
+		return fmt.Sprintf("host%d", rnd.Intn(32))
+	}
+
+	for i := 0; i < 5000; i++ {
+		switch rnd.Intn(8) {
+		case 0, 1, 2:
+			h.Add(randIP(), randAlias(), randAlias())
+		case 3:
+			h.Add(randIP(), "1bad")
+		case 4:
+			h.DelByIP(randIP())
+		case 5:
+			h.DelByAlias(randAlias())
+		case 6:
+			h.RepointAll(randIP())
+		case 7:
+			h.Normalize(NormalizeOptions{Lowercase: true})
+		}
+		if got := h.sortedIPs(); !reflect.DeepEqual(got, h.scanIPs()) {
+			t.Fatalf("step %d: index %v is out of sync", i, got)
+		}
+		if errCheck := h.CheckConsistency(); errCheck != nil {
+			t.Fatalf("step %d: %v", i, errCheck)
+		}
+	}
+
+	// rebuilt on reindex
+	h.ipToAlias[ip_192_168_1_1] = strSet{"manual": {}}
+	h.Reindex()
+	equal(t, h.scanIPs(), h.sortedIPs())
+}
+
+func TestSortedIndexSharedByCopies(t *testing.T) {
+	h := NewWithOptions(Options{SortedIndex: true})
+	h.Add(ip_192_168_1_2, "printer")
+
+	// copy of the value mutates the same table
+	c := h
+	c.Add(ip_127_0_0_1, "localhost")
+	c.DelByIP(ip_192_168_1_2)
+	c.Add(ip_192_168_1_1, "router")
+	equal(t, nil, h.CheckConsistency())
+	equal(t, nil, c.CheckConsistency())
+	equal(t, "127.0.0.1 localhost\n192.168.1.1 router\n", h.String())
+	equal(t, h.String(), c.String())
+}
+
+func TestLosslessLinesSharedByCopies(t *testing.T) {
+	h := NewWithOptions(Options{Lossless: true})
+	c := h
+	c.Read(strings.NewReader(utf8BOM + "# keep\n127.0.0.1   localhost\n"))
+	equal(t, utf8BOM+"# keep\n127.0.0.1   localhost\n", h.String())
+}
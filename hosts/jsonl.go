@@ -0,0 +1,91 @@
+package hosts
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// jsonMapping is a single JSON Lines record.
+type jsonMapping struct {
+	IP    string `json:"ip"`
+	Alias string `json:"alias"`
+}
+
+// jsonRecord is a single JSON Lines record accepted by `ReadJSONL`.
+type jsonRecord struct {
+	IP      string   `json:"ip"`
+	Alias   string   `json:"alias"`
+	Aliases []string `json:"aliases"`
+}
+
+// WriteJSONL writes all mappings as JSON Lines (one `{"ip":"...","alias":"..."}` object per line) sorted by IP address
+// and alias. Records are streamed, so no single document is built for the whole table.
+func (h *Hosts) WriteJSONL(writer io.Writer) error {
+	bufWr := bufio.NewWriter(writer)
+	enc := json.NewEncoder(bufWr)
+	for _, ip := range h.sortedIPs() {
+		addr := ip.String()
+		for _, a := range h.sortedAliases(ip) {
+			if errEnc := enc.Encode(jsonMapping{IP: addr, Alias: a}); errEnc != nil {
+				return errEnc
+			}
+		}
+	}
+	return bufWr.Flush()
+}
+
+// ReadJSONL appends mappings read from JSON Lines, one `{"ip":"...","alias":"..."}` or `{"ip":"...","aliases":[...]}`
+// object per line, validating them like `Read`. Blank lines are ignored and malformed ones are skipped (or reported in
+// strict mode).
+func (h *Hosts) ReadJSONL(reader io.Reader) error {
+	bufRd := bufio.NewReader(reader)
+	st := h.newReadState(false)
+
+	for lineNum := 1; ; lineNum++ {
+		raw, tooLong, errRead := readRawLine(bufRd, h.opts.MaxLineBytes)
+		if errRead != nil && errRead != io.EOF {
+			return errRead
+		}
+		if tooLong {
+			h.skipped(st, lineNum, "", ReasonLineTooLong, nil)
+		} else if strings.TrimSpace(raw) != "" {
+			h.readJSONLine(st, lineNum, raw)
+		}
+		if errRead == io.EOF {
+			return st.err()
+		}
+	}
+}
+
+// readJSONLine parses single JSON Lines record adding its mappings.
+func (h *Hosts) readJSONLine(st *readState, lineNum int, raw string) {
+	var rec jsonRecord
+	if errJson := json.Unmarshal([]byte(raw), &rec); errJson != nil {
+		h.skipped(st, lineNum, raw, ReasonMalformed, errJson)
+		return
+	}
+	ip, errParse := ValidateIP(rec.IP)
+	if errParse != nil {
+		h.skipped(st, lineNum, raw, ReasonInvalidIP, errParse)
+		return
+	}
+	aliases := rec.Aliases
+	if rec.Alias != "" {
+		aliases = append([]string{rec.Alias}, aliases...)
+	}
+	if len(aliases) == 0 {
+		h.skipped(st, lineNum, raw, ReasonIPOnly, nil)
+		return
+	}
+
+	var accept, reject func(string)
+	if h.opts.Strict {
+		accept = func(a string) { st.checkDuplicate(lineNum, h.normalizeIP(ip), a) }
+	}
+	if h.opts.Logger != nil || h.opts.Strict {
+		reject = func(a string) { h.skipped(st, lineNum, raw, ReasonInvalidAlias, h.validateAlias(a)) }
+	}
+	h.add(ip, aliases, "", accept, reject)
+}
@@ -0,0 +1,74 @@
+package hosts
+
+import (
+	"bytes"
+	"errors"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestWriteJSONL(t *testing.T) {
+	h := New()
+	h.Add(ip_192_168_1_1, "b.com", "a.com")
+	h.Add(ip_127_0_0_1, "localhost")
+
+	var buf bytes.Buffer
+	if errWrite := h.WriteJSONL(&buf); errWrite != nil {
+		t.Fatal(errWrite)
+	}
+	equal(t, `{"ip":"127.0.0.1","alias":"localhost"}`+"\n"+
+		`{"ip":"192.168.1.1","alias":"a.com"}`+"\n"+
+		`{"ip":"192.168.1.1","alias":"b.com"}`+"\n", buf.String())
+
+	empty := New()
+	buf.Reset()
+	equal(t, nil, empty.WriteJSONL(&buf))
+	equal(t, 0, buf.Len())
+}
+
+func TestReadJSONL(t *testing.T) {
+	h := New()
+	h.ReadAll(strings.NewReader(exampleInput1), strings.NewReader(exampleInput2))
+
+	var buf bytes.Buffer
+	h.WriteJSONL(&buf)
+	c := New()
+	if errRead := c.ReadJSONL(&buf); errRead != nil {
+		t.Fatal(errRead)
+	}
+	equal(t, true, h.Equal(&c))
+
+	const input = `{"ip":"127.0.0.1","alias":"localhost"}` + "\n\n" +
+		`{"ip":"192.168.1.1","aliases":["b.com","a.com"]}` + "\n" +
+		`{"ip":"192.168.1.2","alias":"c.com","aliases":["1bad.org"]}` + "\n" +
+		`{"ip":"not-an-ip","alias":"x.com"}` + "\n" +
+		`{"ip":"192.168.1.3"}` + "\n" +
+		`{"ip":` + "\n"
+
+	lenient := New()
+	equal(t, nil, lenient.ReadJSONL(strings.NewReader(input)))
+	equal(t, []string{"localhost"}, lenient.GetAlias(ip_127_0_0_1))
+	equalStrArr(t, []string{"a.com", "b.com"}, lenient.GetAlias(ip_192_168_1_1))
+	equal(t, 3, lenient.Len())
+
+	strict := NewWithOptions(Options{Strict: true})
+	errRead := strict.ReadJSONL(strings.NewReader(input))
+	var readErrs ReadErrors
+	equal(t, true, errors.As(errRead, &readErrs))
+
+	reasons := make([]string, 0, len(readErrs))
+	for _, e := range readErrs {
+		var lineErr *LineError
+		errors.As(e, &lineErr)
+		reasons = append(reasons, lineErr.Reason)
+	}
+	equal(t, []string{ReasonInvalidAlias, ReasonInvalidIP, ReasonIPOnly, ReasonMalformed}, reasons)
+	equal(t, 3, strict.Len())
+
+	// no conflict between spellings of the same address
+	unmapped := NewWithOptions(Options{Strict: true, UnmapV4: true})
+	equal(t, nil, unmapped.ReadJSONL(strings.NewReader(`{"ip":"::ffff:192.168.1.1","alias":"router"}`+"\n"+
+		`{"ip":"192.168.1.1","alias":"router"}`+"\n")))
+	equal(t, []netip.Addr{ip_192_168_1_1}, unmapped.GetIP("router"))
+}
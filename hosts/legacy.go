@@ -0,0 +1,25 @@
+package hosts
+
+import (
+	"net"
+	"net/netip"
+)
+
+// AddLegacy works like `Add` but accepts `net.IP`. IPv4 addresses in 16-byte form are stored as plain IPv4.
+func (h *Hosts) AddLegacy(ip net.IP, alias ...string) {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	addr, _ := netip.AddrFromSlice(ip)
+	h.Add(addr, alias...)
+}
+
+// GetIPLegacy works like `GetIP` but returns `net.IP` slice. IPv4 addresses are returned in 4-byte form.
+func (h *Hosts) GetIPLegacy(alias string) []net.IP {
+	ips := h.GetIP(alias)
+	res := make([]net.IP, 0, len(ips))
+	for _, ip := range ips {
+		res = append(res, ip.AsSlice())
+	}
+	return res
+}
@@ -0,0 +1,26 @@
+package hosts
+
+import (
+	"net"
+	"testing"
+)
+
+func TestLegacy(t *testing.T) {
+	h := New()
+	h.AddLegacy(net.ParseIP("192.168.1.1"), "tabs")
+	h.AddLegacy(net.IPv4(192, 168, 1, 2).To4(), "tabs")
+	h.AddLegacy(net.ParseIP("::1"), "localhost")
+	h.AddLegacy(nil, "invalid")
+
+	// v4 addresses are not v4-mapped
+	equalStrArr(t, []string{"192.168.1.1", "192.168.1.2"}, ipArrStr(h.GetIP("tabs")))
+	equal(t, 0, len(h.GetIP("invalid")))
+
+	ips := h.GetIPLegacy("localhost")
+	equal(t, 1, len(ips))
+	equal(t, true, ips[0].Equal(net.IPv6loopback))
+
+	for _, ip := range h.GetIPLegacy("tabs") {
+		equal(t, net.IPv4len, len(ip))
+	}
+}
@@ -0,0 +1,77 @@
+package hosts
+
+import (
+	"bufio"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// srcLine is a line read in lossless mode.
+type srcLine struct {
+	raw     string     // exact content including line ending
+	ip      netip.Addr // invalid for lines without mappings
+	aliases []string   // aliases accepted from this line
+}
+
+// writeLossless writes read lines verbatim (unless their mappings were removed) followed by mappings added later.
+func (h *Hosts) writeLossless(bufWr *bufio.Writer) {
+	written := make(map[mapping]struct{})
+	if h.bom {
+		bufWr.WriteString(utf8BOM)
+	}
+
+	lastRaw := ""
+	for _, line := range h.lines {
+		if !line.ip.IsValid() {
+			bufWr.WriteString(line.raw)
+			lastRaw = line.raw
+			continue
+		}
+
+		present := make([]string, 0, len(line.aliases))
+		for _, a := range line.aliases {
+			if _, okA := h.ipToAlias[line.ip][a]; okA {
+				present = append(present, a)
+				written[mapping{line.ip, a}] = struct{}{}
+			}
+		}
+
+		switch {
+		case len(present) == len(line.aliases):
+			bufWr.WriteString(line.raw)
+			lastRaw = line.raw
+		case len(present) > 0:
+			writeLines(bufWr, h.ipString(line.ip), present, "", defaultLineFormat)
+			lastRaw = "\n"
+		}
+	}
+
+	var added []netip.Addr
+	for ip, aliases := range h.ipToAlias {
+		for a := range aliases {
+			if _, okW := written[mapping{ip, a}]; !okW {
+				added = append(added, ip)
+				break
+			}
+		}
+	}
+	if len(added) == 0 {
+		return
+	}
+	if lastRaw != "" && !strings.HasSuffix(lastRaw, "\n") {
+		bufWr.WriteString("\n")
+	}
+
+	sortAddrs(added)
+	for _, ip := range added {
+		var aliases []string
+		for a := range h.ipToAlias[ip] {
+			if _, okW := written[mapping{ip, a}]; !okW {
+				aliases = append(aliases, a)
+			}
+		}
+		sort.Strings(aliases)
+		writeLines(bufWr, h.ipString(ip), aliases, "", defaultLineFormat)
+	}
+}
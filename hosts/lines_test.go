@@ -0,0 +1,43 @@
+package hosts
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestLosslessRoundTrip(t *testing.T) {
+	inputs := []string{
+		exampleInput1,
+		exampleInput2,
+		"\xEF\xBB\xBF# bom\r\n127.0.0.1\tlocalhost  # inline\r\n\r\n::1 localhost",
+		"",
+	}
+
+	for _, input := range inputs {
+		h := NewWithOptions(Options{Lossless: true})
+		if errRead := h.Read(strings.NewReader(input)); errRead != nil {
+			t.Fatal(errRead)
+		}
+		equal(t, input, h.String())
+	}
+}
+
+func TestLosslessEdits(t *testing.T) {
+	const input = "# header\n127.0.0.1\tlocalhost  the-same # inline\n192.168.1.1   tabs\n::1 localhost"
+
+	h := NewWithOptions(Options{Lossless: true})
+	h.Read(strings.NewReader(input))
+
+	// removed mapping rewrites its line only, new mappings follow read lines
+	h.DelByIP(ip_192_168_1_1)
+	h.Add(ip_192_168_1_2, "added")
+	h.Add(netip.IPv6Loopback(), "added")
+	equal(t, "# header\n127.0.0.1\tlocalhost  the-same # inline\n::1 localhost\n192.168.1.2 added\n::1 added\n",
+		h.String())
+
+	// partially removed line is rewritten
+	h.DelByAlias("the-same")
+	h.Add(ip_127_0_0_1, "localhost")
+	equal(t, "# header\n127.0.0.1 localhost\n::1 localhost\n192.168.1.2 added\n::1 added\n", h.String())
+}
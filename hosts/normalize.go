@@ -0,0 +1,132 @@
+package hosts
+
+import (
+	"net/netip"
+	"strings"
+)
+
+// NormalizeOptions selects cleanup steps performed by `Normalize`.
+type NormalizeOptions struct {
+	// Lowercase converts aliases to lowercase.
+	Lowercase bool
+	// UnmapV4 converts IPv4-mapped IPv6 addresses into plain IPv4 form.
+	UnmapV4 bool
+}
+
+// Normalize rewrites all stored mappings according to provided options, collapsing mappings which become equal.
+// Priority, TTL, touch time and tags of rewritten mappings are kept. Running it again with the same options changes
+// nothing. Fully qualified aliases and aliases parsing as IP addresses are never stored (see `TrimTrailingDot` option
+// for the former), so they need no cleanup.
+func (h *Hosts) Normalize(opts NormalizeOptions) {
+	for _, m := range h.Mappings() {
+		ip, alias := m.IP, m.Alias
+		if opts.UnmapV4 {
+			ip = ip.Unmap()
+		}
+		if opts.Lowercase {
+			alias = strings.ToLower(alias)
+		}
+		if ip == m.IP && alias == m.Alias {
+			continue
+		}
+		h.rewrite(mapping{m.IP, m.Alias}, ip, alias)
+	}
+}
+
+// RepointAll moves every alias to provided IP address (like another sink) and returns amount of moved mappings.
+func (h *Hosts) RepointAll(newIP netip.Addr) int {
+	if !newIP.IsValid() {
+		return 0
+	}
+	newIP = h.normalizeIP(newIP)
+	count := 0
+	for _, m := range h.Mappings() {
+		if m.IP != newIP {
+			h.rewrite(mapping{m.IP, m.Alias}, newIP, m.Alias)
+			count++
+		}
+	}
+	return count
+}
+
+// CoalesceAliases moves all IP addresses of other aliases onto canonical one and removes other aliases. Returns amount
+// of removed aliases (0 if canonical alias is invalid).
+func (h *Hosts) CoalesceAliases(canonical string, others ...string) int {
+	if !h.validAlias(canonical) {
+		return 0
+	}
+	count := 0
+	for _, o := range others {
+		ips, okIps := h.aliasToIp[o]
+		if !okIps || o == canonical {
+			continue
+		}
+		for _, ip := range sortedSet(ips) {
+			h.rewrite(mapping{ip, o}, ip, canonical)
+		}
+		count++
+	}
+	return count
+}
+
+// sortedSet returns addresses of the set in ascending order.
+func sortedSet(ips ipSet) []netip.Addr {
+	res := make([]netip.Addr, 0, len(ips))
+	for ip := range ips {
+		res = append(res, ip)
+	}
+	sortAddrs(res)
+	return res
+}
+
+// rewrite replaces IP:Host mapping with one for provided IP address and alias keeping priority, TTL, touch time, tags,
+// comments and canonical name. Mapping is only removed if new alias is invalid.
+func (h *Hosts) rewrite(old mapping, ip netip.Addr, alias string) {
+	touched, okTouched := h.touched[old]
+	prio, okPrio := h.priority[old]
+	ttl, okTTL := h.ttl[old]
+	tags := h.tags[old]
+	comments := h.comments[old]
+	wasCanon := h.canonical[old.ip] == old.alias
+	h.unlink(old.ip, old.alias)
+	if !h.validAlias(alias) {
+		return
+	}
+
+	h.link(ip, alias)
+	key := mapping{ip, alias}
+	if okTouched && touched.After(h.touched[key]) {
+		h.touched[key] = touched
+	}
+	if okPrio {
+		h.priority[key] = prio
+	}
+	if okTTL {
+		h.ttl[key] = ttl
+	}
+	for t := range tags {
+		h.tag(key, t)
+	}
+	if len(comments) > 0 {
+		h.comments[key] = append(h.comments[key], comments...)
+	}
+	if _, okCanon := h.canonical[ip]; wasCanon && !okCanon {
+		h.canonical[ip] = alias
+	}
+}
+
+// link stores single IP:Host mapping in both maps without any validation.
+func (h *Hosts) link(ip netip.Addr, alias string) {
+	if _, okIp := h.ipToAlias[ip]; !okIp {
+		h.newIP(ip, 1)
+	}
+	if _, okA := h.ipToAlias[ip][alias]; !okA && h.opts.PreserveOrder {
+		h.order[ip] = append(h.order[ip], alias)
+	}
+	h.ipToAlias[ip][alias] = struct{}{}
+
+	if _, okA := h.aliasToIp[alias]; !okA {
+		h.aliasToIp[alias] = make(ipSet, 1)
+	}
+	h.aliasToIp[alias][ip] = struct{}{}
+}
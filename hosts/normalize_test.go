@@ -0,0 +1,65 @@
+package hosts
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	mapped := netip.MustParseAddr("::ffff:192.168.1.1")
+
+	h := NewWithOptions(Options{TrackCanonical: true})
+	h.Add(mapped, "Host.Example.org", "other")
+	h.Add(ip_192_168_1_1, "host.example.org")
+	h.Add(ip_192_168_1_2, "UPPER", "Mixed.example.org")
+
+	opts := NormalizeOptions{Lowercase: true, UnmapV4: true}
+	h.Normalize(opts)
+
+	equal(t, 0, len(h.GetIP("Host.Example.org")))
+	equal(t, []netip.Addr{ip_192_168_1_1}, h.GetIP("host.example.org"))
+	equalStrArr(t, []string{"host.example.org", "other"}, h.GetAlias(ip_192_168_1_1))
+	equal(t, 0, len(h.GetAlias(mapped)))
+	equalStrArr(t, []string{"upper", "mixed.example.org"}, h.GetAlias(ip_192_168_1_2))
+	equal(t, "host.example.org", h.CanonicalName(ip_192_168_1_1))
+	equal(t, 4, len(h.Mappings()))
+
+	// idempotent
+	before := h.Mappings()
+	h.Normalize(opts)
+	equal(t, before, h.Mappings())
+	equal(t, 2, len(h.aliasToIp["host.example.org"])+len(h.aliasToIp["other"]))
+}
+
+func TestRepointAll(t *testing.T) {
+	h := New()
+	h.Add(netip.IPv4Unspecified(), "ads.example.com", "tracker.example.com")
+	h.Add(ip_127_0_0_1, "localhost")
+	h.Add(ip_192_168_1_1, "ads.example.com", "router")
+
+	equal(t, 4, h.RepointAll(ip_127_0_0_1))
+	equal(t, 1, h.Len())
+	equalStrArr(t, []string{"localhost", "ads.example.com", "tracker.example.com", "router"}, h.GetAlias(ip_127_0_0_1))
+	equal(t, []netip.Addr{ip_127_0_0_1}, h.GetIP("ads.example.com"))
+	equal(t, 0, len(h.GetAlias(netip.IPv4Unspecified())))
+
+	equal(t, 0, h.RepointAll(ip_127_0_0_1))
+	equal(t, 0, h.RepointAll(netip.Addr{}))
+}
+
+func TestCoalesceAliases(t *testing.T) {
+	h := NewWithOptions(Options{TrackCanonical: true})
+	h.Add(ip_192_168_1_1, "www.example.com", "other")
+	h.Add(ip_192_168_1_2, "example.com")
+	h.Add(ip_192_168_1_3, "web.example.com")
+
+	equal(t, 2, h.CoalesceAliases("example.com", "www.example.com", "web.example.com", "missing", "example.com"))
+	equal(t, []netip.Addr{ip_192_168_1_1, ip_192_168_1_2, ip_192_168_1_3}, sortedSet(h.aliasToIp["example.com"]))
+	equal(t, 0, len(h.GetIP("www.example.com")))
+	equal(t, "example.com", h.CanonicalName(ip_192_168_1_1))
+	equal(t, nil, h.CheckConsistency())
+
+	// invalid canonical name
+	equal(t, 0, h.CoalesceAliases("1bad", "other"))
+	equal(t, []netip.Addr{ip_192_168_1_1}, h.GetIP("other"))
+}
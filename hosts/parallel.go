@@ -0,0 +1,123 @@
+package hosts
+
+import (
+	"bytes"
+	"io"
+	"net/netip"
+	"sort"
+	"sync"
+)
+
+// ReadParallel appends hosts read from `size` bytes of provided `io.ReaderAt` using up to `workers` goroutines. Input
+// is split into chunks at line boundaries, every chunk is parsed into separate table and the results are merged in
+// order, giving the same mappings as `Read`. Options relying on reading the source in sequence (`Lossless`, `Strict`,
+// `KeepComments` and `Logger`) make it read serially, as do `workers` lower than 2. Error of the first failed chunk is
+// returned and nothing is added then.
+func (h *Hosts) ReadParallel(reader io.ReaderAt, size int64, workers int) error {
+	if workers < 2 || h.opts.Lossless || h.opts.Strict || h.opts.KeepComments || h.opts.Logger != nil {
+		return h.Read(io.NewSectionReader(reader, 0, size))
+	}
+
+	bounds, errBounds := chunkBounds(reader, size, workers)
+	if errBounds != nil {
+		return errBounds
+	}
+	parts := make([]Hosts, len(bounds)-1)
+	errs := make([]error, len(parts))
+
+	var wg sync.WaitGroup
+	for i := range parts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			parts[i] = newHosts(0, h.opts)
+			errs[i] = parts[i].Read(io.NewSectionReader(reader, bounds[i], bounds[i+1]-bounds[i]))
+		}(i)
+	}
+	wg.Wait()
+
+	for _, errRead := range errs {
+		if errRead != nil {
+			return errRead
+		}
+	}
+	for i := range parts {
+		h.mergeRead(&parts[i])
+	}
+	return nil
+}
+
+// chunkBounds returns offsets splitting input into at most `n` chunks, each starting at the beginning of a line.
+// Chunks which would be empty are omitted.
+func chunkBounds(reader io.ReaderAt, size int64, n int) ([]int64, error) {
+	bounds := []int64{0}
+	buf := make([]byte, 4096)
+
+	for i := 1; i < n; i++ {
+		pos := size * int64(i) / int64(n)
+		if last := bounds[len(bounds)-1]; pos <= last {
+			continue
+		}
+		// line starts right after newline, so search from preceding byte
+		start, found := pos-1, false
+		for start < size && !found {
+			cnt, errRead := reader.ReadAt(buf[:min64(int64(len(buf)), size-start)], start)
+			if idx := bytes.IndexByte(buf[:cnt], '\n'); idx > -1 {
+				start, found = start+int64(idx)+1, true
+			} else {
+				start += int64(cnt)
+			}
+			if errRead != nil && errRead != io.EOF {
+				return nil, errRead
+			}
+			if cnt == 0 {
+				break
+			}
+		}
+		if !found || start >= size {
+			break
+		}
+		bounds = append(bounds, start)
+	}
+	return append(bounds, size), nil
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// mergeRead adds mappings read into other table keeping the result identical to reading its source after the
+// content of this table.
+func (h *Hosts) mergeRead(part *Hosts) {
+	ips := make([]netip.Addr, 0, len(part.ipToAlias))
+	for ip := range part.ipToAlias {
+		ips = append(ips, ip)
+	}
+	sort.Slice(ips, func(i, j int) bool { return part.seq[ips[i]] < part.seq[ips[j]] })
+
+	for _, ip := range ips {
+		if _, okIp := h.ipToAlias[ip]; !okIp {
+			h.newIP(ip, len(part.ipToAlias[ip]))
+			if text, okText := part.ipText[ip]; okText {
+				h.ipText[ip] = text
+			}
+			if _, okUpper := part.upperIP[ip]; okUpper {
+				h.upperIP[ip] = struct{}{}
+			}
+		}
+		for _, a := range part.GetAlias(ip) {
+			h.link(ip, a)
+			if ts, okTs := part.touched[mapping{ip, a}]; okTs {
+				h.touched[mapping{ip, a}] = ts
+			}
+		}
+		if canon, okCanon := part.canonical[ip]; okCanon {
+			if _, okOwn := h.canonical[ip]; !okOwn {
+				h.canonical[ip] = canon
+			}
+		}
+	}
+}
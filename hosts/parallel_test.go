@@ -0,0 +1,79 @@
+package hosts
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func parallelInput(lines int) string {
+	var sb strings.Builder
+	for i := 0; i < lines; i++ {
+		fmt.Fprintf(&sb, "10.0.%d.%d host%d.example.com shared%d.example.com # comment\n", i%7, i%250, i, i%13)
+		if i%17 == 0 {
+			sb.WriteString("# comment line\n\n192.168.1.3\n")
+		}
+	}
+	return sb.String()
+}
+
+func TestReadParallel(t *testing.T) {
+	inputs := []string{exampleInput1, exampleInput2, parallelInput(500), "", "\n", "127.0.0.1 localhost"}
+
+	for _, input := range inputs {
+		for _, opts := range []Options{{}, {PreserveOrder: true, TrackCanonical: true}, {Strict: true}} {
+			serial := NewWithOptions(opts)
+			errSerial := serial.Read(strings.NewReader(input))
+
+			for workers := 0; workers <= 9; workers++ {
+				parallel := NewWithOptions(opts)
+				errRead := parallel.ReadParallel(strings.NewReader(input), int64(len(input)), workers)
+				equal(t, fmt.Sprint(errSerial), fmt.Sprint(errRead))
+				equal(t, true, serial.Equal(&parallel))
+				equal(t, serial.String(), parallel.String())
+				equal(t, serial.EntriesInOrder(), parallel.EntriesInOrder())
+			}
+		}
+	}
+}
+
+func TestReadParallelKeepsState(t *testing.T) {
+	input := parallelInput(50)
+	h := New()
+	h.AddWildcard(ip_127_0_0_1, ".example.org")
+	h.AddAlias("short", "host1.example.com")
+
+	errRead := h.ReadParallel(strings.NewReader(input), int64(len(input)), 4)
+	equal(t, nil, errRead)
+	equal(t, []string{"127.0.0.1"}, ipArrStr(h.GetIP("x.example.org")))
+	equal(t, ipArrStr(h.GetIP("host1.example.com")), ipArrStr(h.GetIP("short")))
+	equal(t, 1, len(h.GetIP("short")))
+}
+
+func TestChunkBounds(t *testing.T) {
+	input := "a\nbb\nccc\n\ndddd"
+	bounds, _ := chunkBounds(strings.NewReader(input), int64(len(input)), 4)
+	equal(t, []int64{0, 5, 9, 10, 14}, bounds)
+
+	// single long line can't be split
+	bounds, _ = chunkBounds(strings.NewReader("abcdef"), 6, 3)
+	equal(t, []int64{0, 6}, bounds)
+}
+
+func BenchmarkReadParallel(b *testing.B) {
+	input := parallelInput(100000)
+
+	b.Run("Read", func(bb *testing.B) {
+		for i := 0; i < bb.N; i++ {
+			h := New()
+			h.Read(strings.NewReader(input))
+		}
+	})
+
+	b.Run("ReadParallel", func(bb *testing.B) {
+		for i := 0; i < bb.N; i++ {
+			h := New()
+			h.ReadParallel(strings.NewReader(input), int64(len(input)), 4)
+		}
+	})
+}
@@ -0,0 +1,30 @@
+package hosts
+
+import (
+	"sort"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// SuspiciousPublicSuffixEntries returns sorted aliases which are public suffixes themselves (like `co.uk` or
+// `github.io`). Mapping such alias is almost always a mistake of imported block list, as it breaks every site under
+// the suffix. Nothing is removed.
+func (h *Hosts) SuspiciousPublicSuffixEntries() []string {
+	var res []string
+	for a := range h.aliasToIp {
+		if isPublicSuffix(a) {
+			res = append(res, a)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// isPublicSuffix checks whether name is listed as public suffix. Single labels not listed (like `localhost`) match
+// only the default rule, so they are not considered public suffixes.
+func isPublicSuffix(name string) bool {
+	name = strings.ToLower(name)
+	suffix, icann := publicsuffix.PublicSuffix(name)
+	return suffix == name && (icann || strings.Contains(name, "."))
+}
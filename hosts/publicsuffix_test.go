@@ -0,0 +1,17 @@
+package hosts
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSuspiciousPublicSuffixEntries(t *testing.T) {
+	h := New()
+	h.Read(strings.NewReader("0.0.0.0 co.uk ads.co.uk github.io user.github.io com example.com\n" +
+		"127.0.0.1 localhost my.lan CO.UK\n"))
+
+	equal(t, []string{"CO.UK", "co.uk", "com", "github.io"}, h.SuspiciousPublicSuffixEntries())
+
+	empty := New()
+	equal(t, 0, len(empty.SuspiciousPublicSuffixEntries()))
+}
@@ -0,0 +1,90 @@
+package hosts
+
+import (
+	"net/netip"
+	"path"
+	"sort"
+	"strings"
+)
+
+// AliasEntry is a single alias together with all its IP addresses.
+type AliasEntry struct {
+	Alias string
+	IPs   []netip.Addr
+}
+
+// MatchGlob returns sorted entries restricted to aliases matching `path.Match` style pattern (like
+// `*.doubleclick.net`). Malformed pattern matches nothing.
+func (h *Hosts) MatchGlob(pattern string) []Entry {
+	if _, errPattern := path.Match(pattern, ""); errPattern != nil {
+		return nil
+	}
+
+	var res []Entry
+	for _, e := range h.Entries() {
+		matched := e.Aliases[:0]
+		for _, a := range e.Aliases {
+			if ok, _ := path.Match(pattern, a); ok {
+				matched = append(matched, a)
+			}
+		}
+		if len(matched) > 0 {
+			res = append(res, Entry{IP: e.IP, Aliases: matched})
+		}
+	}
+	return res
+}
+
+// MultiIPAliases returns aliases associated with more than one IP address together with their sorted addresses.
+func (h *Hosts) MultiIPAliases() map[string][]netip.Addr {
+	res := make(map[string][]netip.Addr)
+	for a, ips := range h.aliasToIp {
+		if len(ips) < 2 {
+			continue
+		}
+		res[a] = sortedSet(ips)
+	}
+	return res
+}
+
+// EntriesByAlias returns one entry per alias with its sorted IP addresses, sorted by alias.
+func (h *Hosts) EntriesByAlias() []AliasEntry {
+	res := make([]AliasEntry, 0, len(h.aliasToIp))
+	for a, ips := range h.aliasToIp {
+		res = append(res, AliasEntry{Alias: a, IPs: sortedSet(ips)})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Alias < res[j].Alias })
+	return res
+}
+
+// Query returns sorted mappings with IP address within provided prefix and alias equal to provided suffix or being
+// its subdomain (like `ads.example.com` for `example.com`). Invalid (zero) prefix matches all IP addresses and empty
+// suffix matches all aliases.
+func (h *Hosts) Query(prefix netip.Prefix, aliasSuffix string) []Mapping {
+	suffix := strings.TrimPrefix(aliasSuffix, ".")
+	res := make([]Mapping, 0)
+	for _, m := range h.Mappings() {
+		if prefix.IsValid() && !prefix.Contains(m.IP) {
+			continue
+		}
+		if suffix != "" && m.Alias != suffix && !strings.HasSuffix(m.Alias, "."+suffix) {
+			continue
+		}
+		res = append(res, m)
+	}
+	return res
+}
+
+// Snapshot returns copies of both mappings at once: sorted aliases of every IP address and sorted IP addresses of
+// every alias. Returned maps and slices are owned by the caller, so modifying them doesn't affect the table.
+func (h *Hosts) Snapshot() (forward map[netip.Addr][]string, reverse map[string][]netip.Addr) {
+	forward = make(map[netip.Addr][]string, len(h.ipToAlias))
+	for ip := range h.ipToAlias {
+		forward[ip] = h.sortedAliases(ip)
+	}
+	reverse = make(map[string][]netip.Addr, len(h.aliasToIp))
+	for a, ips := range h.aliasToIp {
+		reverse[a] = sortedSet(ips)
+	}
+	return forward, reverse
+}
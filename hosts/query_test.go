@@ -0,0 +1,89 @@
+package hosts
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	h := New()
+	h.Add(netip.IPv4Unspecified(), "ad.doubleclick.net", "doubleclick.net", "stats.doubleclick.net", "example.com")
+	h.Add(ip_127_0_0_1, "www.doubleclick.net", "localhost")
+
+	equal(t, []Entry{
+		{IP: netip.IPv4Unspecified(), Aliases: []string{"ad.doubleclick.net", "stats.doubleclick.net"}},
+		{IP: ip_127_0_0_1, Aliases: []string{"www.doubleclick.net"}},
+	}, h.MatchGlob("*.doubleclick.net"))
+
+	equal(t, []Entry{{IP: ip_127_0_0_1, Aliases: []string{"localhost"}}}, h.MatchGlob("local?ost"))
+	equal(t, 0, len(h.MatchGlob("*.missing")))
+	equal(t, 0, len(h.MatchGlob("[")))
+}
+
+func TestMultiIPAliases(t *testing.T) {
+	h := New()
+	h.Read(strings.NewReader(exampleInput1))
+
+	equal(t, map[string][]netip.Addr{"tabs": {ip_192_168_1_1, ip_192_168_1_2}}, h.MultiIPAliases())
+
+	e := New()
+	equal(t, map[string][]netip.Addr{}, e.MultiIPAliases())
+}
+
+func TestEntriesByAlias(t *testing.T) {
+	h := New()
+	h.Read(strings.NewReader("192.168.1.2 tabs b.com\n192.168.1.1 tabs spaces\n127.0.0.1 a.com\n"))
+
+	equal(t, []AliasEntry{
+		{Alias: "a.com", IPs: []netip.Addr{ip_127_0_0_1}},
+		{Alias: "b.com", IPs: []netip.Addr{ip_192_168_1_2}},
+		{Alias: "spaces", IPs: []netip.Addr{ip_192_168_1_1}},
+		{Alias: "tabs", IPs: []netip.Addr{ip_192_168_1_1, ip_192_168_1_2}},
+	}, h.EntriesByAlias())
+
+	empty := New()
+	equal(t, []AliasEntry{}, empty.EntriesByAlias())
+}
+
+func TestQuery(t *testing.T) {
+	h := New()
+	h.Read(strings.NewReader("0.0.0.0 ads.example.com example.com badexample.com x.ads.example.com\n" +
+		"0.0.0.1 t.example.com\n127.0.0.1 ads.example.com localhost\n::1 ads.example.com\n"))
+	sink := netip.IPv4Unspecified()
+
+	equal(t, []Mapping{{sink, "ads.example.com"}, {sink, "x.ads.example.com"}},
+		h.Query(netip.MustParsePrefix("0.0.0.0/8"), "ads.example.com"))
+	equal(t, []Mapping{{sink, "ads.example.com"}, {sink, "example.com"}, {sink, "x.ads.example.com"},
+		{netip.MustParseAddr("0.0.0.1"), "t.example.com"}}, h.Query(netip.MustParsePrefix("0.0.0.0/8"), ".example.com"))
+
+	// zero arguments match all
+	equal(t, []Mapping{{ip_127_0_0_1, "ads.example.com"}, {ip_127_0_0_1, "localhost"}},
+		h.Query(netip.MustParsePrefix("127.0.0.0/8"), ""))
+	equal(t, []Mapping{{sink, "ads.example.com"}, {sink, "x.ads.example.com"}, {ip_127_0_0_1, "ads.example.com"},
+		{netip.IPv6Loopback(), "ads.example.com"}}, h.Query(netip.Prefix{}, "ads.example.com"))
+	equal(t, h.Mappings(), h.Query(netip.Prefix{}, ""))
+	equal(t, []Mapping{}, h.Query(netip.MustParsePrefix("10.0.0.0/8"), ""))
+}
+
+func TestSnapshot(t *testing.T) {
+	h := New()
+	h.Read(strings.NewReader("192.168.1.2 tabs b.com\n192.168.1.1 tabs spaces\n"))
+
+	forward, reverse := h.Snapshot()
+	equal(t, map[netip.Addr][]string{
+		ip_192_168_1_1: {"spaces", "tabs"},
+		ip_192_168_1_2: {"b.com", "tabs"},
+	}, forward)
+	equal(t, map[string][]netip.Addr{
+		"b.com":  {ip_192_168_1_2},
+		"spaces": {ip_192_168_1_1},
+		"tabs":   {ip_192_168_1_1, ip_192_168_1_2},
+	}, reverse)
+
+	// copies are independent of the table
+	forward[ip_192_168_1_1][0] = "changed"
+	delete(reverse, "tabs")
+	equal(t, []string{"spaces", "tabs"}, h.sortedAliases(ip_192_168_1_1))
+	equal(t, 2, len(h.GetIP("tabs")))
+}
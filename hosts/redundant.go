@@ -0,0 +1,57 @@
+package hosts
+
+import (
+	"bufio"
+	"io"
+	"net/netip"
+)
+
+// ReasonDuplicate is reported by `FindRedundant` for lines with all mappings present on previous lines.
+const ReasonDuplicate = "duplicate"
+
+// RedundantLine describes a line of hosts file reported by `FindRedundant`.
+type RedundantLine struct {
+	Line   int
+	Text   string
+	Reason string
+}
+
+// FindRedundant reads hosts file and reports lines adding nothing to it: lines with all mappings present on previous
+// lines, lines without aliases and lines with invalid IP address or aliases (reported once per line even if only some
+// aliases are invalid). Lines are validated like by `Read` with default options. Nothing is modified.
+func FindRedundant(reader io.Reader) ([]RedundantLine, error) {
+	var res []RedundantLine
+	h := NewWithOptions(Options{Logger: func(lineNum int, line, reason string) {
+		if last := len(res) - 1; last > -1 && res[last].Line == lineNum && res[last].Reason == reason {
+			return
+		}
+		res = append(res, RedundantLine{Line: lineNum, Text: line, Reason: reason})
+	}})
+	bufRd := bufio.NewReader(reader)
+	st := h.newReadState(false)
+
+	for lineNum := 1; ; lineNum++ {
+		raw, _, errRead := readRawLine(bufRd, 0)
+		if errRead != nil && errRead != io.EOF {
+			return res, errRead
+		}
+		if ip, aliases := parseLine(raw); h.containsAll(ip, aliases) {
+			h.skipped(st, lineNum, raw, ReasonDuplicate, nil)
+		} else if raw != "" {
+			h.readLine(st, lineNum, raw)
+		}
+		if errRead == io.EOF {
+			return res, nil
+		}
+	}
+}
+
+// containsAll checks whether all (at least one) provided aliases are mapped to given IP address.
+func (h *Hosts) containsAll(ip netip.Addr, aliases []string) bool {
+	for _, a := range aliases {
+		if !h.ContainsMapping(ip, a) {
+			return false
+		}
+	}
+	return len(aliases) > 0
+}
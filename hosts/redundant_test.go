@@ -0,0 +1,27 @@
+package hosts
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindRedundant(t *testing.T) {
+	redundant, errFind := FindRedundant(strings.NewReader(exampleInput1 + exampleInput2 +
+		"192.168.1.1 spaces tabs\n192.168.1.1 tabs new\n"))
+	equal(t, nil, errFind)
+
+	reasons := make(map[int]string)
+	for _, r := range redundant {
+		reasons[r.Line] = r.Reason
+	}
+	equal(t, map[int]string{
+		8:  ReasonDuplicate,
+		11: ReasonIPOnly,
+		15: ReasonInvalidAlias,
+		16: ReasonInvalidIP,
+		17: ReasonInvalidIP,
+		18: ReasonDuplicate,
+	}, reasons)
+	equal(t, len(reasons), len(redundant))
+	equal(t, "127.0.0.1 the-same", redundant[0].Text)
+}
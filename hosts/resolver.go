@@ -0,0 +1,81 @@
+package hosts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// ErrNotFound is returned when host has no IP addresses mapped.
+var ErrNotFound = errors.New("host not found")
+
+// Lookuper is the interface for resolving host names into IP addresses.
+type Lookuper interface {
+	Lookup(ctx context.Context, host string) ([]netip.Addr, error)
+}
+
+var _ Lookuper = (*Hosts)(nil)
+
+// Lookup returns sorted IP addresses associated with specified host or `ErrNotFound`.
+func (h *Hosts) Lookup(ctx context.Context, host string) ([]netip.Addr, error) {
+	if errCtx := ctx.Err(); errCtx != nil {
+		return nil, errCtx
+	}
+
+	ips := h.GetIP(host)
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("%s: %w", host, ErrNotFound)
+	}
+	sortAddrs(ips)
+	return ips, nil
+}
+
+// ReverseLookup returns canonical name of specified IP address (or lexically first alias if canonical names are not
+// tracked) like PTR record would. Unlike `GetAlias` it returns single name.
+func (h *Hosts) ReverseLookup(ip netip.Addr) (string, bool) {
+	if canon := h.CanonicalName(ip); canon != "" {
+		return canon, true
+	}
+
+	first, found := "", false
+	for a := range h.ipToAlias[h.normalizeIP(ip)] {
+		if !found || a < first {
+			first, found = a, true
+		}
+	}
+	return first, found
+}
+
+// Resolve returns IP address of specified host falling back to its parent domains, so an entry for `example.com`
+// matches `sub.example.com` as well (commonly used to block domain with all subdomains). The closest match wins (exact
+// alias before wildcard at the same level) and the lowest of its IP addresses is returned. Unlike `GetIP` it does not
+// require exact match.
+func (h *Hosts) Resolve(host string) (netip.Addr, bool) {
+	for name := host; name != ""; {
+		ips, okIps := h.aliasToIp[h.resolveAlias(name)]
+		if !okIps {
+			ips, okIps = h.wildcards[name]
+		}
+		if okIps {
+			return lowestAddr(ips), true
+		}
+		dot := strings.IndexByte(name, '.')
+		if dot < 0 {
+			break
+		}
+		name = name[dot+1:]
+	}
+	return netip.Addr{}, false
+}
+
+func lowestAddr(ips ipSet) netip.Addr {
+	var res netip.Addr
+	for ip := range ips {
+		if !res.IsValid() || ip.Less(res) {
+			res = ip
+		}
+	}
+	return res
+}
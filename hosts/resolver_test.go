@@ -0,0 +1,76 @@
+package hosts
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	h := New()
+	h.Add(ip_192_168_1_2, "tabs")
+	h.Add(ip_192_168_1_1, "tabs")
+
+	// sorted addresses
+	ips, errLookup := h.Lookup(context.Background(), "tabs")
+	equal(t, nil, errLookup)
+	equal(t, []string{"192.168.1.1", "192.168.1.2"}, ipArrStr(ips))
+
+	// not found
+	_, errMissing := h.Lookup(context.Background(), "missing")
+	equal(t, true, errors.Is(errMissing, ErrNotFound))
+
+	// cancelled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, errCancel := h.Lookup(ctx, "tabs")
+	equal(t, context.Canceled, errCancel)
+}
+
+func TestReverseLookup(t *testing.T) {
+	h := New()
+	h.Add(ip_127_0_0_1, "zhost", "localhost")
+
+	name, found := h.ReverseLookup(ip_127_0_0_1)
+	equal(t, "localhost", name)
+	equal(t, true, found)
+
+	_, found = h.ReverseLookup(ip_192_168_1_1)
+	equal(t, false, found)
+
+	// canonical name preferred
+	c := NewWithOptions(Options{TrackCanonical: true})
+	c.Add(ip_127_0_0_1, "zhost", "localhost")
+	name, found = c.ReverseLookup(ip_127_0_0_1)
+	equal(t, "zhost", name)
+	equal(t, true, found)
+}
+
+func TestResolve(t *testing.T) {
+	h := New()
+	h.Add(ip_127_0_0_1, "example.com")
+	h.Add(ip_192_168_1_2, "sub.example.com")
+	h.Add(ip_192_168_1_1, "sub.example.com")
+
+	// exact match
+	ip, found := h.Resolve("example.com")
+	equal(t, ip_127_0_0_1, ip)
+	equal(t, true, found)
+
+	// closest parent wins
+	ip, found = h.Resolve("deep.sub.example.com")
+	equal(t, ip_192_168_1_1, ip)
+	equal(t, true, found)
+
+	ip, found = h.Resolve("other.example.com")
+	equal(t, ip_127_0_0_1, ip)
+	equal(t, true, found)
+
+	// exact lookup unaffected
+	equal(t, 0, len(h.GetIP("other.example.com")))
+
+	_, found = h.Resolve("example.org")
+	equal(t, false, found)
+	_, found = h.Resolve("")
+	equal(t, false, found)
+}
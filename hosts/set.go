@@ -0,0 +1,55 @@
+package hosts
+
+import (
+	"net/netip"
+	"sort"
+)
+
+// Unique returns new `Hosts` instance (with the same options) holding IP:Host mappings present in this instance but
+// in none of the others.
+func (h *Hosts) Unique(others ...*Hosts) Hosts {
+	res := newHosts(0, h.opts)
+	for _, m := range h.Mappings() {
+		found := false
+		for _, o := range others {
+			if _, okA := o.ipToAlias[m.IP][m.Alias]; okA {
+				found = true
+				break
+			}
+		}
+		if !found {
+			res.link(m.IP, m.Alias)
+		}
+	}
+	return res
+}
+
+// Select returns new `Hosts` instance (with the same options) holding only specified aliases together with IP
+// addresses they are mapped to. Absent aliases are skipped.
+func (h *Hosts) Select(aliases ...string) Hosts {
+	res := newHosts(len(aliases), h.opts)
+	for _, a := range aliases {
+		for ip := range h.aliasToIp[a] {
+			res.link(ip, a)
+		}
+	}
+	return res
+}
+
+// AliasDiff returns sorted aliases mapped only to the first IP address and sorted aliases mapped only to the second.
+func (h *Hosts) AliasDiff(a, b netip.Addr) (onlyA, onlyB []string) {
+	setA, setB := h.ipToAlias[h.normalizeIP(a)], h.ipToAlias[h.normalizeIP(b)]
+	return setDiff(setA, setB), setDiff(setB, setA)
+}
+
+// setDiff returns sorted aliases of the first set absent from the second.
+func setDiff(a, b strSet) []string {
+	res := make([]string, 0, len(a))
+	for al := range a {
+		if _, okB := b[al]; !okB {
+			res = append(res, al)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,56 @@
+package hosts
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestUnique(t *testing.T) {
+	h := New()
+	h.Read(strings.NewReader("127.0.0.1 localhost mine\n192.168.1.1 tabs router\n192.168.1.2 tabs\n"))
+	a, b := New(), New()
+	a.Add(ip_127_0_0_1, "localhost")
+	b.Add(ip_192_168_1_1, "tabs", "router")
+	b.Add(ip_192_168_1_3, "mine")
+
+	u := h.Unique(&a, &b)
+	equal(t, "127.0.0.1 mine\n192.168.1.2 tabs\n", u.String())
+	equal(t, nil, u.CheckConsistency())
+
+	// source untouched
+	equal(t, 5, len(h.Mappings()))
+
+	// no others
+	all := h.Unique()
+	equal(t, true, h.Equal(&all))
+}
+
+func TestSelect(t *testing.T) {
+	h := New()
+	h.Read(strings.NewReader(exampleInput1))
+
+	s := h.Select("tabs", "localhost", "missing", "tabs")
+	equal(t, "127.0.0.1 localhost\n192.168.1.1 tabs\n192.168.1.2 tabs\n", s.String())
+	equal(t, nil, s.CheckConsistency())
+
+	// independent of source
+	s.DelByAlias("tabs")
+	equalStrArr(t, []string{"tabs", "spaces"}, h.GetAlias(ip_192_168_1_1))
+	h.DelByIP(ip_127_0_0_1)
+	equal(t, []string{"localhost"}, s.GetAlias(ip_127_0_0_1))
+}
+
+func TestAliasDiff(t *testing.T) {
+	h := New()
+	h.Add(netip.IPv4Unspecified(), "ads.com", "shared.com", "tracker.com")
+	h.Add(ip_127_0_0_1, "shared.com", "localhost")
+
+	onlyA, onlyB := h.AliasDiff(netip.IPv4Unspecified(), ip_127_0_0_1)
+	equal(t, []string{"ads.com", "tracker.com"}, onlyA)
+	equal(t, []string{"localhost"}, onlyB)
+
+	onlyA, onlyB = h.AliasDiff(ip_192_168_1_1, ip_127_0_0_1)
+	equal(t, []string{}, onlyA)
+	equal(t, []string{"localhost", "shared.com"}, onlyB)
+}
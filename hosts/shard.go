@@ -0,0 +1,94 @@
+package hosts
+
+import (
+	"hash/fnv"
+	"net/netip"
+)
+
+// Merge adds all mappings of other `Hosts` instance. Aliases are validated according to options of this instance.
+func (h *Hosts) Merge(other *Hosts) {
+	for _, ip := range other.sortedIPs() {
+		h.Add(ip, other.GetAlias(ip)...)
+	}
+	for ip, domains := range other.wildcardsByIP() {
+		h.AddWildcard(ip, domains...)
+	}
+}
+
+// Shard partitions IP addresses into `n` independent `Hosts` instances by hash of the address, so every IP address
+// with all its aliases lands in exactly one shard. Shards share options of the source and may be written
+// concurrently. Values of `n` lower than 1 are treated as 1.
+func (h *Hosts) Shard(n int) []Hosts {
+	if n < 1 {
+		n = 1
+	}
+	shards := make([]Hosts, n)
+	for i := range shards {
+		shards[i] = newHosts(len(h.ipToAlias)/n, h.opts)
+	}
+
+	for _, ip := range append(h.sortedIPs(), h.wildcardOnlyIPs()...) {
+		hash := fnv.New32a()
+		key, _ := ip.MarshalBinary()
+		hash.Write(key)
+		h.copyIP(&shards[hash.Sum32()%uint32(n)], ip)
+	}
+	return shards
+}
+
+// SplitByFamily partitions IP addresses into independent IPv4 and IPv6 tables sharing options of the source.
+// IPv4-mapped IPv6 addresses go to IPv6 table unless `UnmapV4` is set. Aliases of both families end up in both.
+func (h *Hosts) SplitByFamily() (v4, v6 Hosts) {
+	v4, v6 = newHosts(0, h.opts), newHosts(0, h.opts)
+	for _, ip := range append(h.sortedIPs(), h.wildcardOnlyIPs()...) {
+		if ip.Is4() {
+			h.copyIP(&v4, ip)
+		} else {
+			h.copyIP(&v6, ip)
+		}
+	}
+	return v4, v6
+}
+
+// copyIP copies IP address with all its aliases (and wildcards) and their metadata into other `Hosts` instance.
+func (h *Hosts) copyIP(dst *Hosts, ip netip.Addr) {
+	for a := range h.ipToAlias[ip] {
+		dst.link(ip, a)
+		m := mapping{ip, a}
+		if ts, okTs := h.touched[m]; okTs {
+			dst.touched[m] = ts
+		}
+		if prio, okPrio := h.priority[m]; okPrio {
+			dst.priority[m] = prio
+		}
+		if ttl, okTTL := h.ttl[m]; okTTL {
+			dst.ttl[m] = ttl
+		}
+		for t := range h.tags[m] {
+			dst.tag(m, t)
+		}
+		if comments, okComments := h.comments[m]; okComments {
+			dst.comments[m] = append([]string(nil), comments...)
+		}
+	}
+	if order, okOrder := h.order[ip]; okOrder {
+		dst.order[ip] = append([]string(nil), order...)
+	}
+	if canon, okCanon := h.canonical[ip]; okCanon {
+		dst.canonical[ip] = canon
+	}
+	if text, okText := h.ipText[ip]; okText {
+		dst.ipText[ip] = text
+	}
+	if _, okUpper := h.upperIP[ip]; okUpper {
+		dst.upperIP[ip] = struct{}{}
+	}
+	for d, ips := range h.wildcards {
+		if _, okIp := ips[ip]; okIp {
+			if _, okD := dst.wildcards[d]; !okD {
+				dst.wildcards[d] = make(ipSet, 1)
+			}
+			dst.wildcards[d][ip] = struct{}{}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package hosts
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	a, b := New(), New()
+	a.Read(strings.NewReader(exampleInput1))
+	b.Read(strings.NewReader(exampleInput2))
+
+	u := New()
+	u.ReadAll(strings.NewReader(exampleInput1), strings.NewReader(exampleInput2))
+
+	a.Merge(&b)
+	equal(t, true, a.Equal(&u))
+	equal(t, []string{"good321"}, b.GetAlias(ip_172_16_0_1))
+}
+
+func TestShard(t *testing.T) {
+	h := NewWithOptions(Options{TrackCanonical: true})
+	h.ReadAll(strings.NewReader(exampleInput1), strings.NewReader(exampleInput2))
+
+	shards := h.Shard(3)
+	equal(t, 3, len(shards))
+
+	u := New()
+	total := 0
+	for i := range shards {
+		total += shards[i].Len()
+		u.Merge(&shards[i])
+		for ip := range shards[i].ipToAlias {
+			equal(t, h.CanonicalName(ip), shards[i].CanonicalName(ip))
+		}
+	}
+	equal(t, h.Len(), total)
+	equal(t, true, h.Equal(&u))
+
+	// deterministic
+	equal(t, shards[1].String(), h.Shard(3)[1].String())
+	equal(t, h.String(), h.Shard(0)[0].String())
+}
+
+func TestSplitByFamily(t *testing.T) {
+	h := New()
+	h.Read(strings.NewReader(exampleInput1 + "::1 localhost ipv6-only\n::ffff:192.168.1.1 mapped\n"))
+
+	v4, v6 := h.SplitByFamily()
+	equal(t, 5, v4.Len())
+	equal(t, "::1 ipv6-only localhost\n::ffff:192.168.1.1 mapped\n", v6.String())
+	equalStrArr(t, []string{"localhost", "the-same"}, v4.GetAlias(ip_127_0_0_1))
+
+	union := New()
+	union.Merge(&v4)
+	union.Merge(&v6)
+	equal(t, true, h.Equal(&union))
+
+	// unmapped when normalized
+	u := NewWithOptions(Options{UnmapV4: true})
+	u.Read(strings.NewReader("::ffff:192.168.1.1 mapped\n"))
+	v4, v6 = u.SplitByFamily()
+	equal(t, 1, v4.Len())
+	equal(t, 0, v6.Len())
+}
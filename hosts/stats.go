@@ -0,0 +1,44 @@
+package hosts
+
+import "io"
+
+// ReadStats describes content of hosts file read with `ReadWithStats`.
+type ReadStats struct {
+	// Lines is the amount of lines read (including comments and blank lines).
+	Lines int
+	// IPOnly is the amount of lines holding IP address without any alias, which often are placeholders or entries
+	// with aliases commented out.
+	IPOnly int
+	// InvalidIP is the amount of lines skipped because of invalid (or rejected) IP address.
+	InvalidIP int
+	// InvalidAliases is the amount of invalid aliases skipped.
+	InvalidAliases int
+	// LinesTooLong is the amount of lines skipped because of exceeding `MaxLineBytes`.
+	LinesTooLong int
+}
+
+// ReadWithStats works like `Read` but also returns statistics of read content.
+func (h *Hosts) ReadWithStats(reader io.Reader) (ReadStats, error) {
+	var stats ReadStats
+	st := h.newReadState(false)
+	st.stats = &stats
+	errRead := h.read(reader, st)
+	return stats, errRead
+}
+
+// count records skip of given reason (if statistics are collected).
+func (s *ReadStats) count(reason string) {
+	if s == nil {
+		return
+	}
+	switch reason {
+	case ReasonIPOnly:
+		s.IPOnly++
+	case ReasonInvalidIP, ReasonRejectedIP:
+		s.InvalidIP++
+	case ReasonInvalidAlias:
+		s.InvalidAliases++
+	case ReasonLineTooLong:
+		s.LinesTooLong++
+	}
+}
@@ -0,0 +1,22 @@
+package hosts
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadWithStats(t *testing.T) {
+	h := New()
+	stats, errRead := h.ReadWithStats(strings.NewReader(exampleInput1 + exampleInput2 + "192.168.1.6 # d01\n"))
+	equal(t, nil, errRead)
+	equal(t, ReadStats{Lines: 18, IPOnly: 2, InvalidIP: 2, InvalidAliases: 4}, stats)
+	testCommon(t, &h)
+
+	// adding IP address without aliases is still no-op
+	h.Add(ip_192_168_1_3)
+	equal(t, 0, len(h.GetAlias(ip_192_168_1_3)))
+
+	l := NewWithOptions(Options{MaxLineBytes: 100})
+	stats, _ = l.ReadWithStats(strings.NewReader(exampleInput1))
+	equal(t, 2, stats.LinesTooLong)
+}
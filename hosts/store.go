@@ -0,0 +1,38 @@
+package hosts
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// Store holds `Hosts` instance which can be atomically replaced while being concurrently read. Readers never block
+// and never observe partially built instance. Zero value is ready to use.
+type Store struct {
+	ptr atomic.Pointer[Hosts]
+}
+
+// Load returns current `Hosts` instance or nil if nothing was stored yet. Returned instance must not be modified.
+func (s *Store) Load() *Hosts {
+	return s.ptr.Load()
+}
+
+// Store replaces current `Hosts` instance. Provided instance must not be modified afterwards.
+func (s *Store) Store(h *Hosts) {
+	s.ptr.Store(h)
+}
+
+// Reload reads new `Hosts` instance (using options of the current one) and replaces current instance with it.
+// Current instance is kept on read error.
+func (s *Store) Reload(reader io.Reader) error {
+	var opts Options
+	if cur := s.Load(); cur != nil {
+		opts = cur.opts
+	}
+
+	h := NewWithOptions(opts)
+	if errRead := h.Read(reader); errRead != nil {
+		return errRead
+	}
+	s.Store(&h)
+	return nil
+}
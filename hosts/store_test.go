@@ -0,0 +1,47 @@
+package hosts
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+)
+
+func TestStoreReload(t *testing.T) {
+	var s Store
+	equal(t, true, s.Load() == nil)
+
+	if errReload := s.Reload(strings.NewReader(exampleInput1)); errReload != nil {
+		t.Fatal(errReload)
+	}
+	first := s.Load()
+	equal(t, 5, first.Len())
+
+	// concurrent readers during reloads
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if l := s.Load().Len(); l != 5 && l != 6 {
+					t.Errorf("unexpected length: %d", l)
+				}
+			}
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		s.Reload(strings.NewReader(exampleInput1 + exampleInput2))
+	}
+	wg.Wait()
+	equal(t, 6, s.Load().Len())
+
+	// failed reload keeps current instance
+	errRead := errors.New("broken")
+	equal(t, errRead, s.Reload(iotest.ErrReader(errRead)))
+	equal(t, 6, s.Load().Len())
+
+	// swapped instances are independent
+	equal(t, 5, first.Len())
+}
@@ -0,0 +1,167 @@
+package hosts
+
+import (
+	"bufio"
+	"container/heap"
+	"io"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// MergeWrite writes sorted and deduplicated union of hosts files read from provided sources without loading them into
+// memory. Every source must be sorted by IP address and alias (as `Write` produces), otherwise output is not sorted
+// and duplicates may remain. Only a single line of every source is kept in memory at once.
+func MergeWrite(writer io.Writer, sources ...io.Reader) error {
+	cursors := make(mergeHeap, 0, len(sources))
+	for _, src := range sources {
+		cur := &mergeCursor{bufRd: bufio.NewReader(src)}
+		if errNext := cur.next(); errNext != nil {
+			return errNext
+		}
+		if cur.valid() {
+			cursors = append(cursors, cur)
+		}
+	}
+	heap.Init(&cursors)
+
+	strWr := &streamWriter{bufWr: bufio.NewWriter(writer)}
+	var last Mapping
+	for len(cursors) > 0 {
+		cur := cursors[0]
+		if m := cur.head(); m != last {
+			strWr.add(m.IP.String(), m.Alias)
+			last = m
+		}
+		if errNext := cur.next(); errNext != nil {
+			return errNext
+		}
+		if cur.valid() {
+			heap.Fix(&cursors, 0)
+		} else {
+			heap.Pop(&cursors)
+		}
+	}
+	strWr.end()
+
+	return strWr.bufWr.Flush()
+}
+
+// mergeCursor holds mappings of the current line of single source.
+type mergeCursor struct {
+	bufRd   *bufio.Reader
+	ip      netip.Addr
+	aliases []string
+	eof     bool
+}
+
+func (c *mergeCursor) valid() bool {
+	return len(c.aliases) > 0
+}
+
+func (c *mergeCursor) head() Mapping {
+	return Mapping{IP: c.ip, Alias: c.aliases[0]}
+}
+
+// next advances cursor to the next mapping reading lines until one with valid mappings is found.
+func (c *mergeCursor) next() error {
+	if len(c.aliases) > 1 {
+		c.aliases = c.aliases[1:]
+		return nil
+	}
+	c.aliases = nil
+
+	for !c.eof {
+		raw, errRead := c.bufRd.ReadString('\n')
+		if errRead != nil && errRead != io.EOF {
+			return errRead
+		}
+		c.eof = errRead == io.EOF
+		if c.ip, c.aliases = parseLine(raw); len(c.aliases) > 0 {
+			return nil
+		}
+	}
+	return nil
+}
+
+// parseLine returns IP address and sorted valid aliases of single hosts file line.
+func parseLine(raw string) (netip.Addr, []string) {
+	if idx := strings.IndexAny(raw, `#;`); idx > -1 {
+		raw = raw[0:idx]
+	}
+	fields := strings.Fields(raw)
+	if len(fields) < 2 {
+		return netip.Addr{}, nil
+	}
+	ip, errParse := netip.ParseAddr(fields[0])
+	if errParse != nil {
+		return netip.Addr{}, nil
+	}
+
+	aliases := fields[1:1]
+	for _, a := range fields[1:] {
+		if ValidateAlias(a) == nil {
+			aliases = append(aliases, a)
+		}
+	}
+	sort.Strings(aliases)
+	return ip, aliases
+}
+
+// mergeHeap orders cursors by their current mapping.
+type mergeHeap []*mergeCursor
+
+func (mh mergeHeap) Len() int { return len(mh) }
+
+func (mh mergeHeap) Less(i, j int) bool {
+	a, b := mh[i].head(), mh[j].head()
+	if a.IP != b.IP {
+		return a.IP.Less(b.IP)
+	}
+	return a.Alias < b.Alias
+}
+
+func (mh mergeHeap) Swap(i, j int) { mh[i], mh[j] = mh[j], mh[i] }
+
+func (mh *mergeHeap) Push(x any) { *mh = append(*mh, x.(*mergeCursor)) }
+
+func (mh *mergeHeap) Pop() any {
+	old := *mh
+	cur := old[len(old)-1]
+	*mh = old[:len(old)-1]
+	return cur
+}
+
+// streamWriter writes mappings one by one splitting lines the same way `writeLines` does.
+type streamWriter struct {
+	bufWr   *bufio.Writer
+	addr    string
+	lineLen int
+	count   int
+}
+
+func (s *streamWriter) add(addr, alias string) {
+	if s.count > 0 && addr != s.addr {
+		s.end()
+	}
+	if s.count == 0 {
+		s.bufWr.WriteString(addr)
+		s.addr, s.lineLen = addr, len(addr)
+	} else if s.count%maxAliasesPerLine == 0 || s.lineLen+1+len(alias) > maxLineLength {
+		s.bufWr.WriteString("\n")
+		s.bufWr.WriteString(addr)
+		s.lineLen, s.count = len(addr), 0
+	}
+	s.bufWr.WriteString(" ")
+	s.bufWr.WriteString(alias)
+
+	s.lineLen += 1 + len(alias)
+	s.count++
+}
+
+func (s *streamWriter) end() {
+	if s.count > 0 {
+		s.bufWr.WriteString("\n")
+		s.count = 0
+	}
+}
@@ -0,0 +1,36 @@
+package hosts
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestMergeWrite(t *testing.T) {
+	a, b := New(), New()
+	a.Read(strings.NewReader(exampleInput1))
+	b.Read(strings.NewReader(exampleInput2))
+	b.Add(ip_127_0_0_1, "localhost", "aaa", "zzz")
+	b.Add(ip_192_168_1_4, "d25", "d51")
+
+	var all bytes.Buffer
+	u := New()
+	u.ReadAll(strings.NewReader(a.String()), strings.NewReader(b.String()))
+	u.Write(&all)
+
+	var merged bytes.Buffer
+	if errMerge := MergeWrite(&merged, strings.NewReader(a.String()), strings.NewReader(b.String()),
+		strings.NewReader("# empty\n")); errMerge != nil {
+		t.Fatal(errMerge)
+	}
+	equal(t, all.String(), merged.String())
+
+	// nothing to merge
+	merged.Reset()
+	equal(t, nil, MergeWrite(&merged))
+	equal(t, "", merged.String())
+
+	// read error
+	equal(t, iotest.ErrTimeout, MergeWrite(&merged, iotest.TimeoutReader(strings.NewReader(a.String()))))
+}
@@ -0,0 +1,117 @@
+package hosts
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// LineError describes line (or alias) skipped while reading in strict mode.
+type LineError struct {
+	Line   int
+	Text   string
+	Reason string
+	Err    error // cause of rejection (if known)
+}
+
+func (e *LineError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("line %d: %s: %q: %v", e.Line, e.Reason, e.Text, e.Err)
+	}
+	return fmt.Sprintf("line %d: %s: %q", e.Line, e.Reason, e.Text)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// DuplicateAliasError describes alias mapped to different IP addresses within single read in strict mode.
+type DuplicateAliasError struct {
+	Alias    string
+	IP       netip.Addr
+	Line     int
+	PrevIP   netip.Addr
+	PrevLine int
+}
+
+func (e *DuplicateAliasError) Error() string {
+	return fmt.Sprintf("line %d: alias %q mapped to %s conflicts with %s from line %d",
+		e.Line, e.Alias, e.IP, e.PrevIP, e.PrevLine)
+}
+
+// ReadErrors aggregates all errors found while reading in strict mode (or errors of failed readers with
+// `ContinueOnReaderError` option).
+type ReadErrors []error
+
+func (e ReadErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Is reports whether any of aggregated errors matches target, so `errors.Is` looks into all of them.
+func (e ReadErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of aggregated errors matching target, so `errors.As` looks into all of them.
+func (e ReadErrors) As(target any) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// readState holds state of single read.
+type readState struct {
+	withPriority bool
+	errs         ReadErrors
+	seen         map[string]seenAlias
+	comments     []string
+	tag          string
+	stats        *ReadStats
+}
+
+type seenAlias struct {
+	ip   netip.Addr
+	line int
+}
+
+func (h *Hosts) newReadState(withPriority bool) *readState {
+	st := &readState{withPriority: withPriority}
+	if h.opts.Strict {
+		st.seen = make(map[string]seenAlias)
+	}
+	return st
+}
+
+// checkDuplicate records alias occurrence reporting conflict with previous one mapped to different IP address.
+func (st *readState) checkDuplicate(lineNum int, ip netip.Addr, alias string) {
+	prev, okPrev := st.seen[alias]
+	if !okPrev {
+		st.seen[alias] = seenAlias{ip: ip, line: lineNum}
+		return
+	}
+	if prev.ip != ip {
+		st.errs = append(st.errs, &DuplicateAliasError{
+			Alias: alias, IP: ip, Line: lineNum, PrevIP: prev.ip, PrevLine: prev.line,
+		})
+	}
+}
+
+func (st *readState) err() error {
+	if len(st.errs) == 0 {
+		return nil
+	}
+	return st.errs
+}
@@ -0,0 +1,57 @@
+package hosts
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReadStrict(t *testing.T) {
+	const input = "192.168.1.1 shared one\nnot-an-ip host\n192.168.1.2 shared two\n192.168.1.1 one\n"
+
+	// lenient by default
+	h := New()
+	if errRead := h.Read(strings.NewReader(input)); errRead != nil {
+		t.Fatal(errRead)
+	}
+
+	s := NewWithOptions(Options{Strict: true})
+	errRead := s.Read(strings.NewReader(input))
+
+	var readErrs ReadErrors
+	if !errors.As(errRead, &readErrs) {
+		t.Fatalf("expected ReadErrors, got %v", errRead)
+	}
+	equal(t, 2, len(readErrs))
+
+	var lineErr *LineError
+	equal(t, true, errors.As(errRead, &lineErr))
+	equal(t, 2, lineErr.Line)
+	equal(t, ReasonInvalidIP, lineErr.Reason)
+	equal(t, true, errors.Is(errRead, ErrNotAnIP))
+
+	var dupErr *DuplicateAliasError
+	equal(t, true, errors.As(errRead, &dupErr))
+	equal(t, "shared", dupErr.Alias)
+	equal(t, ip_192_168_1_2, dupErr.IP)
+	equal(t, 3, dupErr.Line)
+	equal(t, ip_192_168_1_1, dupErr.PrevIP)
+	equal(t, 1, dupErr.PrevLine)
+
+	// valid entries still loaded
+	equal(t, true, h.Equal(&s))
+
+	// duplicates across separate reads are not reported
+	equal(t, nil, s.Read(strings.NewReader("192.168.1.3 shared\n")))
+}
+
+func TestReadStrictReasons(t *testing.T) {
+	s := NewWithOptions(Options{Strict: true})
+	errRead := s.Read(strings.NewReader("010.0.10.1 tabs\n127.0.0.1 1bad.org localhost\n"))
+
+	equal(t, true, errors.Is(errRead, ErrLeadingZeros))
+	equal(t, true, errors.Is(errRead, ErrAliasInvalidStart))
+	equal(t, "line 1: invalid-ip: \"010.0.10.1 tabs\": \"010.0.10.1\": IPv4 address has leading zeros\n"+
+		"line 2: invalid-alias: \"127.0.0.1 1bad.org localhost\": \"1bad.org\": alias does not start with a letter",
+		errRead.Error())
+}
@@ -0,0 +1,71 @@
+package hosts
+
+import (
+	"net/netip"
+	"os"
+	"runtime"
+	"sort"
+)
+
+// SystemHostsPath returns location of OS hosts file.
+func SystemHostsPath() string {
+	return systemHostsPath(runtime.GOOS, os.Getenv)
+}
+
+func systemHostsPath(goos string, getenv func(string) string) string {
+	if goos == "windows" {
+		root := getenv("SystemRoot")
+		if root == "" {
+			root = `C:\Windows`
+		}
+		return root + `\System32\drivers\etc\hosts`
+	}
+	return "/etc/hosts"
+}
+
+// UpdateSystemHosts replaces managed block in OS hosts file with mappings from `Hosts` instance leaving the rest of
+// the file untouched. File is replaced atomically and keeps its permissions.
+func (h *Hosts) UpdateSystemHosts(marker string) error {
+	return updateFile(SystemHostsPath(), marker, h, false)
+}
+
+// LoadSystemWithOverrides reads OS hosts file and maps every hostname from provided overrides to its IP address only,
+// replacing mappings of this hostname read from the file. Invalid hostnames are skipped.
+func LoadSystemWithOverrides(overrides map[string]netip.Addr) (Hosts, error) {
+	return loadWithOverrides(SystemHostsPath(), overrides)
+}
+
+func loadWithOverrides(path string, overrides map[string]netip.Addr) (Hosts, error) {
+	h := New()
+	file, errOpen := os.Open(path)
+	if errOpen != nil {
+		return h, errOpen
+	}
+	defer file.Close()
+	if errRead := h.Read(file); errRead != nil {
+		return h, errRead
+	}
+
+	aliases := make([]string, 0, len(overrides))
+	for a := range overrides {
+		aliases = append(aliases, a)
+	}
+	sort.Strings(aliases)
+	for _, a := range aliases {
+		h.override(overrides[a], a)
+	}
+	return h, nil
+}
+
+// override maps alias only to specified IP address removing its other mappings. Invalid alias is left untouched.
+func (h *Hosts) override(ip netip.Addr, alias string) {
+	if !ip.IsValid() || !h.validAlias(alias) {
+		return
+	}
+	for old := range h.aliasToIp[alias] {
+		if old != h.normalizeIP(ip) {
+			h.unlink(old, alias)
+		}
+	}
+	h.Add(ip, alias)
+}
@@ -0,0 +1,40 @@
+package hosts
+
+import (
+	"errors"
+	"io/fs"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSystemHostsPath(t *testing.T) {
+	env := map[string]string{"SystemRoot": `D:\Win`}
+	getenv := func(key string) string { return env[key] }
+
+	equal(t, "/etc/hosts", systemHostsPath("linux", getenv))
+	equal(t, "/etc/hosts", systemHostsPath("darwin", getenv))
+	equal(t, `D:\Win\System32\drivers\etc\hosts`, systemHostsPath("windows", getenv))
+
+	// fallback when environment is not set
+	delete(env, "SystemRoot")
+	equal(t, `C:\Windows\System32\drivers\etc\hosts`, systemHostsPath("windows", getenv))
+}
+
+func TestLoadWithOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hosts")
+	os.WriteFile(path, []byte("127.0.0.1 localhost dev.local\n192.168.1.1 api.local dev.local\n"), 0o644)
+
+	h, errLoad := loadWithOverrides(path, map[string]netip.Addr{
+		"dev.local": ip_192_168_1_2,
+		"new.local": ip_192_168_1_2,
+		"api.local": ip_192_168_1_1,
+		"1bad.org":  ip_192_168_1_2,
+	})
+	equal(t, nil, errLoad)
+	equal(t, "127.0.0.1 localhost\n192.168.1.1 api.local\n192.168.1.2 dev.local new.local\n", h.String())
+
+	_, errMissing := loadWithOverrides(filepath.Join(t.TempDir(), "missing"), nil)
+	equal(t, true, errors.Is(errMissing, fs.ErrNotExist))
+}
@@ -0,0 +1,73 @@
+package hosts
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/netip"
+	"sort"
+)
+
+const tagHeaderFormat = "# === source: %s ===\n"
+
+// ReadTagged appends hosts read from file using provided `io.Reader` tagging every read mapping with source tag (like
+// name of the block list). Mapping read from multiple sources keeps all their tags.
+func (h *Hosts) ReadTagged(reader io.Reader, tag string) error {
+	st := h.newReadState(false)
+	st.tag = tag
+	return h.read(reader, st)
+}
+
+// Tags returns sorted source tags of IP:Host mapping read with `ReadTagged`.
+func (h *Hosts) Tags(ip netip.Addr, alias string) []string {
+	tags := h.tags[mapping{h.normalizeIP(ip), alias}]
+	res := make([]string, 0, len(tags))
+	for t := range tags {
+		res = append(res, t)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func (h *Hosts) tag(m mapping, tag string) {
+	if _, okM := h.tags[m]; !okM {
+		h.tags[m] = make(strSet, 1)
+	}
+	h.tags[m][tag] = struct{}{}
+}
+
+// writeByTag writes untagged mappings followed by sections of mappings grouped by tag. Mapping with multiple tags is
+// written in section of its lexically first tag.
+func (h *Hosts) writeByTag(bufWr *bufio.Writer, format lineFormat) {
+	sections := make(map[string]map[netip.Addr][]string)
+	for _, m := range h.Mappings() {
+		tag := ""
+		if tags := h.Tags(m.IP, m.Alias); len(tags) > 0 {
+			tag = tags[0]
+		}
+		if _, okTag := sections[tag]; !okTag {
+			sections[tag] = make(map[netip.Addr][]string)
+		}
+		sections[tag][m.IP] = append(sections[tag][m.IP], m.Alias)
+	}
+
+	tags := make([]string, 0, len(sections))
+	for tag := range sections {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	for _, tag := range tags {
+		if tag != "" {
+			fmt.Fprintf(bufWr, tagHeaderFormat, tag)
+		}
+		ips := make([]netip.Addr, 0, len(sections[tag]))
+		for ip := range sections[tag] {
+			ips = append(ips, ip)
+		}
+		sortAddrs(ips)
+		for _, ip := range ips {
+			writeLines(bufWr, h.ipString(ip), sections[tag][ip], "", format)
+		}
+	}
+}
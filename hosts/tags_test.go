@@ -0,0 +1,37 @@
+package hosts
+
+import (
+	"bytes"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestReadTagged(t *testing.T) {
+	sink := netip.IPv4Unspecified()
+	h := New()
+	h.ReadTagged(strings.NewReader("0.0.0.0 ads.example.com shared.example.com\n"), "stevenblack")
+	h.ReadTagged(strings.NewReader("0.0.0.0 shared.example.com tracker.example.com\n"), "adaway")
+	h.Add(ip_127_0_0_1, "localhost")
+
+	equal(t, []string{"adaway", "stevenblack"}, h.Tags(sink, "shared.example.com"))
+	equal(t, []string{"stevenblack"}, h.Tags(sink, "ads.example.com"))
+	equal(t, []string{}, h.Tags(ip_127_0_0_1, "localhost"))
+
+	var buf bytes.Buffer
+	if errWrite := h.WriteWithOptions(&buf, WriteOptions{GroupByTag: true}); errWrite != nil {
+		t.Fatal(errWrite)
+	}
+	equal(t, "127.0.0.1 localhost\n"+
+		"# === source: adaway ===\n0.0.0.0 shared.example.com tracker.example.com\n"+
+		"# === source: stevenblack ===\n0.0.0.0 ads.example.com\n", buf.String())
+
+	// sections are regular hosts file
+	r := New()
+	r.Read(&buf)
+	equal(t, true, h.Equal(&r))
+
+	// tags dropped with mapping
+	h.DelByAlias("shared.example.com")
+	equal(t, 0, len(h.tags))
+}
@@ -0,0 +1,151 @@
+package hosts
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const trieMagic = "HTT\x01"
+
+// Trie is a set of host names organized by reversed labels (`ads.example.com` is stored as `com` -> `example` ->
+// `ads`) which answers suffix queries in time proportional to amount of labels of queried host.
+type Trie struct {
+	root trieNode
+}
+
+type trieNode struct {
+	terminal bool
+	children map[string]*trieNode
+}
+
+// MarshalTrie encodes all aliases as reversed label trie. Encoded data starts with magic `HTT\x01` followed by the root
+// node. Every node is encoded as a byte (1 if the name ending at this node is stored, 0 otherwise), uvarint amount of
+// children and then every child (sorted by label) as uvarint label length, label bytes and the child node itself.
+// Only the set of aliases is encoded (without IP addresses).
+func (h *Hosts) MarshalTrie() ([]byte, error) {
+	var t Trie
+	for a := range h.aliasToIp {
+		t.add(a)
+	}
+
+	buf := bytes.NewBufferString(trieMagic)
+	t.root.encode(buf)
+	return buf.Bytes(), nil
+}
+
+// UnmarshalTrie decodes trie encoded by `MarshalTrie`.
+func UnmarshalTrie(data []byte) (*Trie, error) {
+	if !bytes.HasPrefix(data, []byte(trieMagic)) {
+		return nil, fmt.Errorf("%w: bad header", ErrCorruptData)
+	}
+	rd := bytes.NewReader(data[len(trieMagic):])
+
+	t := &Trie{}
+	if errDecode := t.root.decode(rd); errDecode != nil {
+		return nil, errDecode
+	}
+	if rd.Len() > 0 {
+		return nil, fmt.Errorf("%w: trailing data", ErrCorruptData)
+	}
+	return t, nil
+}
+
+// Contains reports whether exactly specified host is stored.
+func (t *Trie) Contains(host string) bool {
+	labels := strings.Split(host, ".")
+	node := &t.root
+	for i := len(labels) - 1; i >= 0 && node != nil; i-- {
+		node = node.children[labels[i]]
+	}
+	return node != nil && node.terminal
+}
+
+// MatchSuffix returns the longest stored name equal to specified host or being its parent domain.
+func (t *Trie) MatchSuffix(host string) (string, bool) {
+	labels := strings.Split(host, ".")
+	node, match := &t.root, -1
+	for i := len(labels) - 1; i >= 0; i-- {
+		if node = node.children[labels[i]]; node == nil {
+			break
+		}
+		if node.terminal {
+			match = i
+		}
+	}
+	if match < 0 {
+		return "", false
+	}
+	return strings.Join(labels[match:], "."), true
+}
+
+func (t *Trie) add(name string) {
+	labels := strings.Split(name, ".")
+	node := &t.root
+	for i := len(labels) - 1; i >= 0; i-- {
+		if node.children == nil {
+			node.children = make(map[string]*trieNode)
+		}
+		next, okNext := node.children[labels[i]]
+		if !okNext {
+			next = &trieNode{}
+			node.children[labels[i]] = next
+		}
+		node = next
+	}
+	node.terminal = true
+}
+
+func (n *trieNode) encode(buf *bytes.Buffer) {
+	var tmp [binary.MaxVarintLen64]byte
+	if n.terminal {
+		buf.WriteByte(1)
+	} else {
+		buf.WriteByte(0)
+	}
+	buf.Write(tmp[:binary.PutUvarint(tmp[:], uint64(len(n.children)))])
+
+	labels := make([]string, 0, len(n.children))
+	for l := range n.children {
+		labels = append(labels, l)
+	}
+	sort.Strings(labels)
+	for _, l := range labels {
+		buf.Write(tmp[:binary.PutUvarint(tmp[:], uint64(len(l)))])
+		buf.WriteString(l)
+		n.children[l].encode(buf)
+	}
+}
+
+func (n *trieNode) decode(rd *bytes.Reader) error {
+	flag, errFlag := rd.ReadByte()
+	if errFlag != nil || flag > 1 {
+		return fmt.Errorf("%w: bad node", ErrCorruptData)
+	}
+	n.terminal = flag == 1
+
+	count, errCount := binary.ReadUvarint(rd)
+	if errCount != nil || count > uint64(rd.Len()) {
+		return fmt.Errorf("%w: bad child count", ErrCorruptData)
+	}
+	if count > 0 {
+		n.children = make(map[string]*trieNode, count)
+	}
+	for i := uint64(0); i < count; i++ {
+		size, errSize := binary.ReadUvarint(rd)
+		if errSize != nil || size == 0 || size > uint64(rd.Len()) {
+			return fmt.Errorf("%w: bad label length", ErrCorruptData)
+		}
+		label := make([]byte, size)
+		rd.Read(label)
+
+		child := &trieNode{}
+		if errChild := child.decode(rd); errChild != nil {
+			return errChild
+		}
+		n.children[string(label)] = child
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package hosts
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMarshalTrie(t *testing.T) {
+	h := New()
+	h.Read(strings.NewReader("0.0.0.0 ads.example.com doubleclick.net\n127.0.0.1 localhost\n" +
+		"0.0.0.0 deep.ads.example.com\n"))
+
+	data, errMarshal := h.MarshalTrie()
+	equal(t, nil, errMarshal)
+
+	trie, errUnmarshal := UnmarshalTrie(data)
+	if errUnmarshal != nil {
+		t.Fatal(errUnmarshal)
+	}
+
+	equal(t, true, trie.Contains("ads.example.com"))
+	equal(t, true, trie.Contains("localhost"))
+	equal(t, false, trie.Contains("example.com"))
+	equal(t, false, trie.Contains("x.localhost"))
+
+	for host, expected := range map[string]string{
+		"ads.example.com":        "ads.example.com",
+		"x.ads.example.com":      "ads.example.com",
+		"x.deep.ads.example.com": "deep.ads.example.com",
+		"stats.doubleclick.net":  "doubleclick.net",
+		"example.com":            "",
+		"net":                    "",
+	} {
+		match, found := trie.MatchSuffix(host)
+		equal(t, expected, match)
+		equal(t, expected != "", found)
+	}
+
+	// deterministic
+	again, _ := h.MarshalTrie()
+	equal(t, data, again)
+
+	// corrupt data
+	for _, bad := range [][]byte{nil, data[:len(data)-1], append(append([]byte{}, data...), 0), []byte(trieMagic + "\x02")} {
+		_, errBad := UnmarshalTrie(bad)
+		equal(t, true, errors.Is(errBad, ErrCorruptData))
+	}
+}
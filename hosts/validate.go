@@ -0,0 +1,117 @@
+package hosts
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+const maxAliasLength = 253
+
+// Reasons of alias rejection returned by `ValidateAlias`.
+var (
+	ErrAliasEmpty        = errors.New("alias is empty")
+	ErrAliasTooShort     = errors.New("alias is shorter than 2 characters")
+	ErrAliasTooLong      = errors.New("alias is longer than 253 characters")
+	ErrAliasIsIP         = errors.New("alias is an IP address")
+	ErrAliasInvalidStart = errors.New("alias does not start with a letter")
+	ErrAliasInvalidEnd   = errors.New("alias does not end with a letter or digit")
+	ErrAliasInvalidChar  = errors.New("alias contains invalid character")
+)
+
+// ValidateAlias checks whether alias can be stored returning error wrapping one of `ErrAlias...` reasons otherwise.
+// Valid alias starts with a letter, ends with a letter or digit and contains only letters, digits, hyphens and dots.
+func ValidateAlias(alias string) error {
+	return validateAlias(alias, aliasRules{})
+}
+
+// aliasRules relaxes rules of alias validation.
+type aliasRules struct {
+	underscore   bool
+	leadingDigit bool
+}
+
+func validateAlias(alias string, rules aliasRules) error {
+	var reason error
+	switch {
+	case alias == "":
+		reason = ErrAliasEmpty
+	case len(alias) < 2:
+		reason = ErrAliasTooShort
+	case len(alias) > maxAliasLength:
+		reason = ErrAliasTooLong
+	case !isLetter(alias[0]) && !(rules.leadingDigit && isDigit(alias[0])):
+		reason = ErrAliasInvalidStart
+	case rules.leadingDigit && isDigit(alias[0]) && isIPText(alias):
+		reason = ErrAliasIsIP
+	default:
+		for i := 1; i < len(alias); i++ {
+			if c := alias[i]; !isLetter(c) && !isDigit(c) && c != '-' && c != '.' && !(rules.underscore && c == '_') {
+				reason = ErrAliasInvalidChar
+				break
+			}
+		}
+		if last := alias[len(alias)-1]; reason == nil && !isLetter(last) && !isDigit(last) {
+			reason = ErrAliasInvalidEnd
+		}
+	}
+
+	if reason == nil {
+		return nil
+	}
+	if reason != ErrAliasEmpty && reason != ErrAliasTooLong && isIPText(alias) {
+		reason = ErrAliasIsIP
+	}
+	return fmt.Errorf("%q: %w", alias, reason)
+}
+
+// Reasons of IP address rejection returned by `ValidateIP`.
+var (
+	ErrNotAnIP      = errors.New("not an IP address")
+	ErrLeadingZeros = errors.New("IPv4 address has leading zeros")
+)
+
+// ValidateIP parses IP address returning error wrapping one of `ErrNotAnIP` or `ErrLeadingZeros` reasons for
+// rejected text. IPv4 address with leading zeros (like `010.0.10.1`) is rejected as it is ambiguous (may be octal).
+func ValidateIP(s string) (netip.Addr, error) {
+	ip, errParse := netip.ParseAddr(s)
+	if errParse == nil {
+		return ip, nil
+	}
+
+	reason := ErrNotAnIP
+	if hasLeadingZeros(s) {
+		reason = ErrLeadingZeros
+	}
+	return netip.Addr{}, fmt.Errorf("%q: %w", s, reason)
+}
+
+// hasLeadingZeros reports whether text is dotted decimal IPv4 address with leading zeros in some octets.
+func hasLeadingZeros(s string) bool {
+	octets := strings.Split(s, ".")
+	if len(octets) != 4 {
+		return false
+	}
+	zeros := false
+	for _, o := range octets {
+		if !isDigits(o) || len(o) > 3 {
+			return false
+		}
+		zeros = zeros || (len(o) > 1 && o[0] == '0')
+	}
+	return zeros
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIPText(s string) bool {
+	_, errParse := netip.ParseAddr(s)
+	return errParse == nil
+}
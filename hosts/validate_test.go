@@ -0,0 +1,76 @@
+package hosts
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateAlias(t *testing.T) {
+	for alias, expected := range map[string]error{
+		"localhost":              nil,
+		"good321":                nil,
+		"sub-domain.example.com": nil,
+		"":                       ErrAliasEmpty,
+		"a":                      ErrAliasTooShort,
+		strings.Repeat("a", 254): ErrAliasTooLong,
+		"127.0.0.1":              ErrAliasIsIP,
+		"::1":                    ErrAliasIsIP,
+		"1bad.org":               ErrAliasInvalidStart,
+		".looked.ok":             ErrAliasInvalidStart,
+		"-dash.org":              ErrAliasInvalidStart,
+		"totaly$%@wrong":         ErrAliasInvalidChar,
+		"under_score.org":        ErrAliasInvalidChar,
+		"this.is.bad.too.":       ErrAliasInvalidEnd,
+		"dash-":                  ErrAliasInvalidEnd,
+		strings.Repeat("a", 253): nil,
+	} {
+		errValidate := ValidateAlias(alias)
+		if expected == nil {
+			equal(t, nil, errValidate)
+			continue
+		}
+		equal(t, true, errors.Is(errValidate, expected))
+	}
+	equal(t, `"1bad.org": alias does not start with a letter`, ValidateAlias("1bad.org").Error())
+}
+
+func TestValidateIP(t *testing.T) {
+	ip, errValidate := ValidateIP("192.168.1.1")
+	equal(t, nil, errValidate)
+	equal(t, ip_192_168_1_1, ip)
+
+	for s, expected := range map[string]error{
+		"010.0.10.1":   ErrLeadingZeros,
+		"192.168.01.1": ErrLeadingZeros,
+		"not-an-ip":    ErrNotAnIP,
+		"1.2.3":        ErrNotAnIP,
+		"01.2.3":       ErrNotAnIP,
+		"0100.0.0.1":   ErrNotAnIP,
+		"":             ErrNotAnIP,
+	} {
+		_, errValidate = ValidateIP(s)
+		equal(t, true, errors.Is(errValidate, expected))
+	}
+}
+
+func TestAllowUnderscoreAndLeadingDigit(t *testing.T) {
+	const input = "192.168.1.1 my_service 1password.com 1.2.3.4 _start end_ 123 good\n"
+
+	h := New()
+	h.Read(strings.NewReader(input))
+	equal(t, []string{"good"}, h.GetAlias(ip_192_168_1_1))
+
+	u := NewWithOptions(Options{AllowUnderscore: true})
+	u.Read(strings.NewReader(input))
+	equalStrArr(t, []string{"good", "my_service"}, u.GetAlias(ip_192_168_1_1))
+
+	d := NewWithOptions(Options{AllowLeadingDigit: true})
+	d.Read(strings.NewReader(input))
+	equalStrArr(t, []string{"1password.com", "123", "good"}, d.GetAlias(ip_192_168_1_1))
+	equal(t, true, errors.Is(d.validateAlias("1.2.3.4"), ErrAliasIsIP))
+
+	// package level validation stays strict
+	equal(t, true, errors.Is(ValidateAlias("my_service"), ErrAliasInvalidChar))
+	equal(t, true, errors.Is(ValidateAlias("1password.com"), ErrAliasInvalidStart))
+}
@@ -0,0 +1,124 @@
+package hosts
+
+import (
+	"bufio"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// AddWildcard adds wildcard mappings matching domain and all its subdomains (written as `.example.com` like
+// dnsmasq does). Leading dot of provided domains is optional. Wildcards are stored separately from exact aliases and
+// are consulted by `GetIP` and `Resolve` only when no exact alias matches. They are compared by `Equal`, copied by
+// `Merge`, `Shard` and `SplitByFamily`, encoded by `MarshalBinary` and removed by `DelByIP`, but don't count into
+// `Len`. Wildcards are not written to hosts file (unless `Wildcards` write option is set) as the format cannot express
+// them. Invalid domains are skipped.
+func (h *Hosts) AddWildcard(ip netip.Addr, domain ...string) {
+	if !ip.IsValid() {
+		return
+	}
+	ip = h.normalizeIP(ip)
+	if !h.acceptIP(ip) {
+		return
+	}
+	for _, d := range domain {
+		d = strings.TrimPrefix(d, ".")
+		if !h.validAlias(d) {
+			continue
+		}
+		if _, okD := h.wildcards[d]; !okD {
+			h.wildcards[d] = make(ipSet, 1)
+		}
+		h.wildcards[d][ip] = struct{}{}
+	}
+}
+
+// wildcardIPInto appends IP addresses of the closest wildcard matching alias to provided slice.
+func (h *Hosts) wildcardIPInto(alias string, dst []netip.Addr) []netip.Addr {
+	for name := alias; name != ""; {
+		if ips, okIps := h.wildcards[name]; okIps {
+			for ip := range ips {
+				dst = append(dst, ip)
+			}
+			return dst
+		}
+		dot := strings.IndexByte(name, '.')
+		if dot < 0 {
+			break
+		}
+		name = name[dot+1:]
+	}
+	return dst
+}
+
+// writeWildcards writes wildcard mappings in leading dot form sorted by IP address and domain.
+func (h *Hosts) writeWildcards(bufWr *bufio.Writer, format lineFormat) {
+	byIP := h.wildcardsByIP()
+	ips := make([]netip.Addr, 0, len(byIP))
+	for ip := range byIP {
+		ips = append(ips, ip)
+	}
+	sortAddrs(ips)
+
+	for _, ip := range ips {
+		dotted := make([]string, 0, len(byIP[ip]))
+		for _, d := range byIP[ip] {
+			dotted = append(dotted, "."+d)
+		}
+		writeLines(bufWr, h.ipString(ip), dotted, "", format)
+	}
+}
+
+// delWildcards removes all wildcards of IP address.
+func (h *Hosts) delWildcards(ip netip.Addr) {
+	for d, ips := range h.wildcards {
+		delete(ips, ip)
+		if len(ips) == 0 {
+			delete(h.wildcards, d)
+		}
+	}
+}
+
+// wildcardsByIP returns sorted wildcard domains of every IP address.
+func (h *Hosts) wildcardsByIP() map[netip.Addr][]string {
+	byIP := make(map[netip.Addr][]string)
+	for d, ips := range h.wildcards {
+		for ip := range ips {
+			byIP[ip] = append(byIP[ip], d)
+		}
+	}
+	for _, domains := range byIP {
+		sort.Strings(domains)
+	}
+	return byIP
+}
+
+// wildcardOnlyIPs returns sorted IP addresses having wildcards but no aliases.
+func (h *Hosts) wildcardOnlyIPs() []netip.Addr {
+	var res []netip.Addr
+	for ip := range h.wildcardsByIP() {
+		if _, okIp := h.ipToAlias[ip]; !okIp {
+			res = append(res, ip)
+		}
+	}
+	sortAddrs(res)
+	return res
+}
+
+func equalWildcards(a, b map[string]ipSet) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for d, ips := range a {
+		otherIps := b[d]
+		if len(ips) != len(otherIps) {
+			return false
+		}
+		for ip := range ips {
+			if _, okIp := otherIps[ip]; !okIp {
+				return false
+			}
+		}
+	}
+	return true
+}
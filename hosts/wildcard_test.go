@@ -0,0 +1,91 @@
+package hosts
+
+import (
+	"bytes"
+	"errors"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestAddWildcard(t *testing.T) {
+	h := New()
+	h.AddWildcard(ip_127_0_0_1, ".example.com", "other.org", ".1bad")
+	h.Add(ip_192_168_1_1, "exact.example.com")
+
+	// wildcard matches domain itself and all subdomains
+	equal(t, []netip.Addr{ip_127_0_0_1}, h.GetIP("example.com"))
+	equal(t, []netip.Addr{ip_127_0_0_1}, h.GetIP("a.b.example.com"))
+	equal(t, []netip.Addr{ip_127_0_0_1}, h.GetIP("sub.other.org"))
+	equal(t, 0, len(h.GetIP("notexample.com")))
+
+	// exact alias takes precedence
+	equal(t, []netip.Addr{ip_192_168_1_1}, h.GetIP("exact.example.com"))
+	ip, found := h.Resolve("deep.exact.example.com")
+	equal(t, ip_192_168_1_1, ip)
+	equal(t, true, found)
+
+	// stored separately from exact aliases
+	equal(t, 1, h.Len())
+	equal(t, 0, len(h.GetAlias(ip_127_0_0_1)))
+
+	// written only on request
+	equal(t, "192.168.1.1 exact.example.com\n", h.String())
+	var canonical strings.Builder
+	equal(t, nil, h.WriteCanonical(&canonical))
+	equal(t, h.String(), canonical.String())
+	strict := NewWithOptions(Options{Strict: true})
+	equal(t, nil, strict.Read(strings.NewReader(h.String())))
+	var buf bytes.Buffer
+	if errWrite := h.WriteWithOptions(&buf, WriteOptions{Wildcards: true}); errWrite != nil {
+		t.Fatal(errWrite)
+	}
+	equal(t, "192.168.1.1 exact.example.com\n127.0.0.1 .example.com .other.org\n", buf.String())
+}
+
+func TestWildcardsInTableOperations(t *testing.T) {
+	h := New()
+	h.Add(ip_192_168_1_1, "router")
+	h.AddWildcard(ip_127_0_0_1, ".example.com")
+	h.AddWildcard(netip.IPv6Loopback(), ".example.org")
+
+	// compared
+	other := New()
+	other.Add(ip_192_168_1_1, "router")
+	equal(t, false, h.Equal(&other))
+
+	// merged
+	other.Merge(&h)
+	equal(t, true, h.Equal(&other))
+
+	// encoded
+	data, _ := h.MarshalBinary()
+	decoded := New()
+	equal(t, nil, decoded.UnmarshalBinary(data))
+	equal(t, true, h.Equal(&decoded))
+	equal(t, []netip.Addr{ip_127_0_0_1}, decoded.GetIP("x.example.com"))
+	equal(t, true, errors.Is(decoded.UnmarshalBinary(append(data[:len(data):len(data)], 0)), ErrCorruptData))
+
+	// copied into shards and families
+	merged := New()
+	for _, shard := range h.Shard(3) {
+		merged.Merge(&shard)
+	}
+	equal(t, true, h.Equal(&merged))
+	v4, v6 := h.SplitByFamily()
+	equal(t, []netip.Addr{ip_127_0_0_1}, v4.GetIP("x.example.com"))
+	equal(t, []netip.Addr{netip.IPv6Loopback()}, v6.GetIP("x.example.org"))
+	equal(t, 0, len(v4.GetIP("x.example.org")))
+
+	// kept when replaced by read content
+	replaced, _ := h.ReadReplaceIfChanged(strings.NewReader("192.168.1.1 router\n"))
+	equal(t, false, replaced)
+	replaced, _ = h.ReadReplaceIfChanged(strings.NewReader("192.168.1.2 router\n"))
+	equal(t, true, replaced)
+	equal(t, []netip.Addr{ip_127_0_0_1}, h.GetIP("x.example.com"))
+
+	// removed with IP address
+	h.DelByIP(ip_127_0_0_1)
+	equal(t, 0, len(h.GetIP("x.example.com")))
+	equal(t, []netip.Addr{netip.IPv6Loopback()}, h.GetIP("x.example.org"))
+}
@@ -0,0 +1,374 @@
+package hosts
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WriteOptions controls format of written hosts file.
+type WriteOptions struct {
+	// GroupBySink writes the sink address (unspecified or loopback) holding the most aliases first, separated from
+	// the rest of entries with an empty line. This matches the layout of redistributed block lists.
+	GroupBySink bool
+	// Priority writes priorities read with `ReadWithPriority` as trailing token of a line. Aliases of given IP address
+	// are grouped into lines by priority.
+	Priority bool
+	// OneHostPerLine writes every IP:Host mapping in separate line so adding or removing single mapping changes
+	// exactly one line of output.
+	OneHostPerLine bool
+	// Separator is written between IP address and aliases (and between aliases). Must consist of spaces or tabs only.
+	// Defaults to single space.
+	Separator string
+	// GroupByTag writes mappings grouped into sections by source tag given to `ReadTagged`, each preceded by header
+	// comment like `# === source: name ===`. Untagged mappings are written first. Takes precedence over
+	// `GroupBySink` and `Priority`.
+	GroupByTag bool
+	// AliasesPerLine limits amount of aliases written in a single line. Zero means no limit, so lines are split only
+	// when exceeding 255 characters. `Write` uses `DefaultAliasesPerLine`.
+	AliasesPerLine int
+	// Packing selects how aliases of single IP address are distributed into lines.
+	Packing Packing
+	// Timestamp (if set) is written in header comment like `# Generated: 2006-01-02T15:04:05Z` above the entries.
+	Timestamp time.Time
+	// ExcludeHeader omits timestamp header from output (and so from `ByteSize` and `Checksum`), which gives checksum
+	// changing only when entries do.
+	ExcludeHeader bool
+	// MaxOutputBytes makes writing fail with `ErrOutputTooLarge` before writing anything if output would exceed given
+	// amount of bytes. Zero means unlimited.
+	MaxOutputBytes int64
+	// Wildcards writes wildcards added with `AddWildcard` in leading dot form (like `0.0.0.0 .example.com`) after all
+	// other entries. `Read` rejects this form, so such output is meant only for resolvers understanding it (like
+	// dnsmasq).
+	Wildcards bool
+}
+
+// Packing selects distribution of aliases into lines.
+type Packing int
+
+const (
+	// PackingAlphabetical writes aliases in alphabetical order.
+	PackingAlphabetical Packing = iota
+	// PackingCompact packs aliases (longest first) into as few lines as possible, which trades alphabetical order
+	// (kept only within every line) for smaller output. The first alias (like canonical name) stays first.
+	PackingCompact
+)
+
+// DefaultAliasesPerLine is the limit of aliases per line used by `Write`.
+const DefaultAliasesPerLine = maxAliasesPerLine
+
+// ErrInvalidSeparator is returned when `WriteOptions.Separator` is not whitespace.
+var ErrInvalidSeparator = errors.New("separator must consist of spaces or tabs")
+
+// ErrOutputTooLarge is returned when written output would exceed `WriteOptions.MaxOutputBytes`.
+var ErrOutputTooLarge = errors.New("output exceeds size limit")
+
+// lineFormat describes layout of aliases in lines.
+type lineFormat struct {
+	sep     string
+	perLine int
+	compact bool
+}
+
+var defaultLineFormat = lineFormat{sep: " ", perLine: maxAliasesPerLine}
+
+func (opts WriteOptions) lineFormat() (lineFormat, error) {
+	format := lineFormat{sep: " ", perLine: opts.AliasesPerLine}
+	if opts.Separator != "" {
+		if strings.Trim(opts.Separator, " \t") != "" {
+			return format, ErrInvalidSeparator
+		}
+		format.sep = opts.Separator
+	}
+	if opts.OneHostPerLine {
+		format.perLine = 1
+	}
+	format.compact = opts.Packing == PackingCompact
+	return format, nil
+}
+
+// Write writes all mappings from `Hosts` instance to hosts file using provided `io.Writer`. Entries are sorted by IP
+// address and alias.
+func (h *Hosts) Write(writer io.Writer) error {
+	return h.WriteWithOptions(writer, WriteOptions{AliasesPerLine: DefaultAliasesPerLine})
+}
+
+// WriteTo implements `io.WriterTo` writing the same content as `Write` and returning amount of bytes written.
+func (h *Hosts) WriteTo(writer io.Writer) (int64, error) {
+	return h.writeTo(writer, WriteOptions{AliasesPerLine: DefaultAliasesPerLine})
+}
+
+// WriteWithOptions writes all mappings from `Hosts` instance to hosts file formatted according to provided options
+// Options are ignored for content read in lossless mode.
+func (h *Hosts) WriteWithOptions(writer io.Writer, opts WriteOptions) error {
+	_, errWrite := h.writeTo(writer, opts)
+	return errWrite
+}
+
+// WriteTopN writes only `n` IP addresses with the most aliases (ties are ordered by address) in regular format.
+func (h *Hosts) WriteTopN(writer io.Writer, n int) error {
+	ips := h.sortedIPs()
+	sort.SliceStable(ips, func(i, j int) bool { return len(h.ipToAlias[ips[i]]) > len(h.ipToAlias[ips[j]]) })
+	if n < 0 {
+		n = 0
+	}
+	if n < len(ips) {
+		ips = ips[:n]
+	}
+
+	bufWr := bufio.NewWriter(writer)
+	for _, ip := range ips {
+		h.writeEntry(bufWr, ip, WriteOptions{}, defaultLineFormat)
+	}
+	return bufWr.Flush()
+}
+
+// ByteSize returns exact amount of bytes that would be written with provided options without buffering the output.
+func (h *Hosts) ByteSize(opts WriteOptions) int64 {
+	opts.MaxOutputBytes = 0
+	n, _ := h.writeTo(io.Discard, opts)
+	return n
+}
+
+// Checksum returns hex encoded SHA-256 of content that would be written with provided options (usable as `ETag`).
+// Set `ExcludeHeader` for checksum ignoring timestamp. Empty string is returned for invalid options.
+func (h *Hosts) Checksum(opts WriteOptions) string {
+	opts.MaxOutputBytes = 0
+	hash := sha256.New()
+	if _, errWrite := h.writeTo(hash, opts); errWrite != nil {
+		return ""
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (h *Hosts) writeTo(writer io.Writer, opts WriteOptions) (int64, error) {
+	format, errFormat := opts.lineFormat()
+	if errFormat != nil {
+		return 0, errFormat
+	}
+	if opts.MaxOutputBytes > 0 && h.ByteSize(opts) > opts.MaxOutputBytes {
+		return 0, ErrOutputTooLarge
+	}
+	cntWr := &countingWriter{writer: writer}
+	bufWr := bufio.NewWriter(cntWr)
+	if h.opts.Lossless && len(h.lines) > 0 {
+		h.writeLossless(bufWr)
+		errFlush := bufWr.Flush()
+		return cntWr.count, errFlush
+	}
+
+	if !opts.Timestamp.IsZero() && !opts.ExcludeHeader {
+		bufWr.WriteString("# Generated: " + opts.Timestamp.UTC().Format(time.RFC3339) + "\n")
+	}
+
+	if opts.GroupByTag {
+		h.writeByTag(bufWr, format)
+		if opts.Wildcards {
+			h.writeWildcards(bufWr, format)
+		}
+		errFlush := bufWr.Flush()
+		return cntWr.count, errFlush
+	}
+
+	ips := h.sortedIPs()
+	if opts.GroupBySink {
+		if sink, count := h.dominantIP(true); count > 0 {
+			h.writeEntry(bufWr, sink, opts, format)
+			if len(ips) > 1 {
+				bufWr.WriteString("\n")
+			}
+			ips = removeAddr(ips, sink)
+		}
+	}
+
+	for _, ip := range ips {
+		h.writeEntry(bufWr, ip, opts, format)
+	}
+	if opts.Wildcards {
+		h.writeWildcards(bufWr, format)
+	}
+
+	errFlush := bufWr.Flush()
+	return cntWr.count, errFlush
+}
+
+// writeEntry writes all aliases of single IP address splitting them into multiple lines if needed.
+func (h *Hosts) writeEntry(bufWr *bufio.Writer, ip netip.Addr, opts WriteOptions, format lineFormat) {
+	addr := h.ipString(ip)
+	var aliases []string
+	if h.opts.PreserveOrder {
+		aliases = h.GetAlias(ip)
+	} else {
+		aliases = h.sortedAliases(ip)
+		if canon, okCanon := h.canonical[ip]; okCanon {
+			aliases = moveToFront(aliases, canon)
+		}
+	}
+
+	if !opts.Priority {
+		h.writeSections(bufWr, ip, aliases, format, nil)
+		return
+	}
+	for _, a := range aliases {
+		for _, c := range h.comments[mapping{ip, a}] {
+			bufWr.WriteString(c)
+			bufWr.WriteString("\n")
+		}
+	}
+
+	byPrio := make(map[int][]string)
+	for _, a := range aliases {
+		prio := h.priority[mapping{ip, a}]
+		byPrio[prio] = append(byPrio[prio], a)
+	}
+	prios := make([]int, 0, len(byPrio))
+	for prio := range byPrio {
+		prios = append(prios, prio)
+	}
+	sort.Ints(prios)
+
+	for _, prio := range prios {
+		suffix := ""
+		if prio != 0 {
+			suffix = format.sep + strconv.Itoa(prio)
+		}
+		writeLines(bufWr, addr, byPrio[prio], suffix, format)
+	}
+}
+
+// writeLines writes aliases of single IP address splitting them into multiple lines according to provided format.
+// Suffix (if any) is appended to every line.
+func writeLines(bufWr *bufio.Writer, addr string, aliases []string, suffix string, format lineFormat) {
+	if format.compact && len(aliases) > 1 {
+		aliases = packAliases(len(addr)+len(suffix), aliases, format)
+	}
+	lineLen := len(addr) + len(suffix)
+	aliasCount := 0
+
+	bufWr.WriteString(addr)
+	for _, alias := range aliases {
+		full := format.perLine > 0 && aliasCount%format.perLine == 0
+		if aliasCount > 0 && (full || lineLen+len(format.sep)+len(alias) > maxLineLength) {
+			bufWr.WriteString(suffix)
+			bufWr.WriteString("\n")
+			bufWr.WriteString(addr)
+			lineLen = len(addr) + len(suffix)
+			aliasCount = 0
+		}
+		bufWr.WriteString(format.sep)
+		bufWr.WriteString(alias)
+
+		lineLen += len(format.sep) + len(alias)
+		aliasCount++
+	}
+	bufWr.WriteString(suffix)
+	bufWr.WriteString("\n")
+}
+
+// packAliases reorders aliases so that splitting them into lines in order gives lines packed with first-fit
+// decreasing heuristic. Every alias of a line doesn't fit into any previous line, which makes the split reproduce
+// packed lines exactly.
+func packAliases(baseLen int, aliases []string, format lineFormat) []string {
+	type line struct {
+		aliases []string
+		length  int
+	}
+	rest := append([]string(nil), aliases[1:]...)
+	sort.SliceStable(rest, func(i, j int) bool { return len(rest[i]) > len(rest[j]) })
+	lines := []line{{aliases: aliases[:1:1], length: baseLen + len(format.sep) + len(aliases[0])}}
+
+	for _, a := range rest {
+		size := len(format.sep) + len(a)
+		placed := false
+		for i := range lines {
+			full := format.perLine > 0 && len(lines[i].aliases) >= format.perLine
+			if !full && lines[i].length+size <= maxLineLength {
+				lines[i].aliases = append(lines[i].aliases, a)
+				lines[i].length += size
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			lines = append(lines, line{aliases: []string{a}, length: baseLen + size})
+		}
+	}
+
+	res := make([]string, 0, len(aliases))
+	for i, l := range lines {
+		first := 0
+		if i == 0 {
+			first = 1
+		}
+		sort.Strings(l.aliases[first:])
+		res = append(res, l.aliases...)
+	}
+	return res
+}
+
+// sortedIPs returns all mapped IP addresses in ascending order.
+func (h *Hosts) sortedIPs() []netip.Addr {
+	if h.opts.SortedIndex {
+		return append(make([]netip.Addr, 0, len(h.index)), h.index...)
+	}
+	return h.scanIPs()
+}
+
+// scanIPs collects and sorts all mapped IP addresses.
+func (h *Hosts) scanIPs() []netip.Addr {
+	ips := make([]netip.Addr, 0, len(h.ipToAlias))
+	for ip := range h.ipToAlias {
+		ips = append(ips, ip)
+	}
+	sortAddrs(ips)
+	return ips
+}
+
+func removeAddr(ips []netip.Addr, ip netip.Addr) []netip.Addr {
+	res := ips[:0]
+	for _, addr := range ips {
+		if addr != ip {
+			res = append(res, addr)
+		}
+	}
+	return res
+}
+
+// removeStr removes specified string from slice keeping order of the rest.
+func removeStr(strs []string, s string) []string {
+	res := strs[:0]
+	for _, str := range strs {
+		if str != s {
+			res = append(res, str)
+		}
+	}
+	return res
+}
+
+// moveToFront moves specified string to the front of sorted slice keeping order of the rest.
+func moveToFront(strs []string, s string) []string {
+	if idx := sort.SearchStrings(strs, s); idx < len(strs) && strs[idx] == s {
+		copy(strs[1:idx+1], strs[:idx])
+		strs[0] = s
+	}
+	return strs
+}
+
+// countingWriter counts bytes written to the underlying writer.
+type countingWriter struct {
+	writer io.Writer
+	count  int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.count += int64(n)
+	return n, err
+}
@@ -0,0 +1,298 @@
+package hosts
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteGroupBySink(t *testing.T) {
+	h := New()
+	h.Add(ip_192_168_1_1, "router")
+	h.Add(netip.IPv4Unspecified(), "ads", "tracker", "malware")
+	h.Add(ip_127_0_0_1, "localhost")
+	h.Add(ip_172_16_0_1, "intranet")
+
+	// sorted by default
+	equal(t, "0.0.0.0 ads malware tracker\n127.0.0.1 localhost\n172.16.0.1 intranet\n192.168.1.1 router\n", h.String())
+
+	// dominant sink goes first
+	h.Add(netip.IPv6Unspecified(), "a1", "a2", "a3", "a4")
+	var buf bytes.Buffer
+	if errWrite := h.WriteWithOptions(&buf, WriteOptions{GroupBySink: true}); errWrite != nil {
+		t.Fatal(errWrite)
+	}
+	equal(t, ":: a1 a2 a3 a4\n\n0.0.0.0 ads malware tracker\n127.0.0.1 localhost\n172.16.0.1 intranet\n"+
+		"192.168.1.1 router\n", buf.String())
+
+	// no sink present
+	s := New()
+	s.Add(ip_192_168_1_1, "router")
+	buf.Reset()
+	s.WriteWithOptions(&buf, WriteOptions{GroupBySink: true})
+	equal(t, "192.168.1.1 router\n", buf.String())
+}
+
+func TestWriteOneHostPerLine(t *testing.T) {
+	h := New()
+	h.Add(ip_127_0_0_1, "localhost", "the-same")
+	h.Add(netip.IPv4Unspecified(), "ads", "tracker")
+
+	write := func() []string {
+		var buf bytes.Buffer
+		if errWrite := h.WriteWithOptions(&buf, WriteOptions{OneHostPerLine: true}); errWrite != nil {
+			t.Fatal(errWrite)
+		}
+		return strings.SplitAfter(buf.String(), "\n")
+	}
+
+	before := write()
+	equal(t, "0.0.0.0 ads\n0.0.0.0 tracker\n127.0.0.1 localhost\n127.0.0.1 the-same\n", strings.Join(before, ""))
+
+	// single insertion produces single added line
+	h.Add(netip.IPv4Unspecified(), "malware")
+	after := write()
+	equal(t, len(before)+1, len(after))
+
+	added := 0
+	for i, j := 0, 0; j < len(after); j++ {
+		if i < len(before) && before[i] == after[j] {
+			i++
+			continue
+		}
+		added++
+		equal(t, "0.0.0.0 malware\n", after[j])
+	}
+	equal(t, 1, added)
+}
+
+func TestByteSize(t *testing.T) {
+	h := New()
+	h.Read(strings.NewReader(exampleInput1))
+	h.ReadWithPriority(strings.NewReader("127.0.0.1 prio 10\n"))
+
+	for _, opts := range []WriteOptions{{}, {GroupBySink: true}, {Priority: true}, {OneHostPerLine: true}} {
+		var buf bytes.Buffer
+		if errWrite := h.WriteWithOptions(&buf, opts); errWrite != nil {
+			t.Fatal(errWrite)
+		}
+		equal(t, int64(buf.Len()), h.ByteSize(opts))
+	}
+
+	// matches io.WriterTo
+	var buf bytes.Buffer
+	n, errWrite := h.WriteTo(&buf)
+	equal(t, nil, errWrite)
+	equal(t, int64(buf.Len()), n)
+	equal(t, n, h.ByteSize(WriteOptions{AliasesPerLine: DefaultAliasesPerLine}))
+}
+
+func TestWriteAliasesPerLine(t *testing.T) {
+	h := New()
+	aliases := make([]string, 0, 100)
+	for i := 0; i < 100; i++ {
+		aliases = append(aliases, fmt.Sprintf("h%02d", i))
+	}
+	h.Add(ip_127_0_0_1, aliases...)
+
+	// default limit
+	equal(t, 12, strings.Count(h.String(), "\n"))
+	var nine bytes.Buffer
+	h.WriteWithOptions(&nine, WriteOptions{AliasesPerLine: DefaultAliasesPerLine})
+	equal(t, h.String(), nine.String())
+
+	// unlimited count, split only on width: "127.0.0.1" + 61 * " hNN" = 253 characters
+	var buf bytes.Buffer
+	if errWrite := h.WriteWithOptions(&buf, WriteOptions{}); errWrite != nil {
+		t.Fatal(errWrite)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	equal(t, 2, len(lines))
+	equal(t, 253, len(lines[0]))
+	equal(t, 61, strings.Count(lines[0], " "))
+
+	// custom limit
+	buf.Reset()
+	h.WriteWithOptions(&buf, WriteOptions{AliasesPerLine: 50})
+	equal(t, 2, strings.Count(buf.String(), "\n"))
+}
+
+func TestChecksum(t *testing.T) {
+	h := New()
+	h.Read(strings.NewReader(exampleInput1))
+
+	for _, opts := range []WriteOptions{{}, {OneHostPerLine: true}} {
+		var buf bytes.Buffer
+		if errWrite := h.WriteWithOptions(&buf, opts); errWrite != nil {
+			t.Fatal(errWrite)
+		}
+		sum := sha256.Sum256(buf.Bytes())
+		equal(t, hex.EncodeToString(sum[:]), h.Checksum(opts))
+	}
+
+	// stable until content changes
+	before := h.Checksum(WriteOptions{})
+	equal(t, before, h.Checksum(WriteOptions{}))
+	h.Add(ip_127_0_0_1, "changed")
+	equal(t, false, before == h.Checksum(WriteOptions{}))
+
+	equal(t, "", h.Checksum(WriteOptions{Separator: "x"}))
+}
+
+func TestWriteTimestamp(t *testing.T) {
+	h := New()
+	h.Add(ip_127_0_0_1, "localhost")
+
+	var opts WriteOptions
+	opts.Timestamp = time.Date(2024, 5, 6, 7, 8, 9, 0, time.FixedZone("CEST", 2*3600))
+	var buf bytes.Buffer
+	if errWrite := h.WriteWithOptions(&buf, opts); errWrite != nil {
+		t.Fatal(errWrite)
+	}
+	equal(t, "# Generated: 2024-05-06T05:08:09Z\n127.0.0.1 localhost\n", buf.String())
+	equal(t, int64(buf.Len()), h.ByteSize(opts))
+
+	sum := sha256.Sum256(buf.Bytes())
+	equal(t, hex.EncodeToString(sum[:]), h.Checksum(opts))
+
+	// header excluded on request
+	later := opts
+	later.Timestamp = opts.Timestamp.Add(time.Hour)
+	equal(t, false, h.Checksum(later) == h.Checksum(opts))
+	opts.ExcludeHeader, later.ExcludeHeader = true, true
+	equal(t, h.Checksum(WriteOptions{}), h.Checksum(opts))
+	equal(t, h.Checksum(later), h.Checksum(opts))
+	equal(t, h.ByteSize(WriteOptions{}), h.ByteSize(opts))
+}
+
+func TestWriteSeparator(t *testing.T) {
+	h := New()
+	h.Add(ip_127_0_0_1, "localhost", "the-same")
+
+	var buf bytes.Buffer
+	if errWrite := h.WriteWithOptions(&buf, WriteOptions{Separator: "\t"}); errWrite != nil {
+		t.Fatal(errWrite)
+	}
+	equal(t, "127.0.0.1\tlocalhost\tthe-same\n", buf.String())
+
+	// output remains parseable
+	r := New()
+	r.Read(&buf)
+	equal(t, h.Entries(), r.Entries())
+
+	// only whitespace allowed
+	for _, sep := range []string{",", " x ", "\n"} {
+		equal(t, ErrInvalidSeparator, h.WriteWithOptions(&buf, WriteOptions{Separator: sep}))
+	}
+}
+
+func TestWriteTopN(t *testing.T) {
+	h := New()
+	h.Add(ip_192_168_1_2, "bb", "cc")
+	h.Add(netip.IPv4Unspecified(), "ads", "tracker", "malware")
+	h.Add(ip_127_0_0_1, "localhost")
+	h.Add(ip_192_168_1_1, "aa", "dd")
+
+	var buf bytes.Buffer
+	if errWrite := h.WriteTopN(&buf, 3); errWrite != nil {
+		t.Fatal(errWrite)
+	}
+	equal(t, "0.0.0.0 ads malware tracker\n192.168.1.1 aa dd\n192.168.1.2 bb cc\n", buf.String())
+
+	buf.Reset()
+	h.WriteTopN(&buf, 10)
+	equal(t, 4, strings.Count(buf.String(), "\n"))
+
+	buf.Reset()
+	h.WriteTopN(&buf, -1)
+	equal(t, "", buf.String())
+}
+
+func TestWriteZonedIPv6RoundTrip(t *testing.T) {
+	zoned := netip.MustParseAddr("fe80::1%eth0")
+
+	h := New()
+	h.Add(zoned, "router.lan")
+	h.Add(netip.MustParseAddr("fe80::1"), "unzoned.lan")
+	long := make([]string, 0, 30)
+	for i := 0; i < 30; i++ {
+		long = append(long, fmt.Sprintf("this-is-quite-a-long-host-%02d.lan", i))
+	}
+	h.Add(netip.MustParseAddr("fe80::2%enp0s31f6"), long...)
+
+	var buf bytes.Buffer
+	if errWrite := h.Write(&buf); errWrite != nil {
+		t.Fatal(errWrite)
+	}
+	equal(t, true, strings.Contains(buf.String(), "fe80::1%eth0 router.lan\n"))
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		equal(t, true, len(line) <= maxLineLength)
+	}
+
+	r := New()
+	if errRead := r.Read(&buf); errRead != nil {
+		t.Fatal(errRead)
+	}
+	equal(t, true, h.Equal(&r))
+	equal(t, []netip.Addr{zoned}, r.GetIP("router.lan"))
+	equal(t, false, r.ContainsMapping(netip.MustParseAddr("fe80::1"), "router.lan"))
+}
+
+func TestWritePackingCompact(t *testing.T) {
+	h := NewWithOptions(Options{TrackCanonical: true})
+	h.Add(ip_127_0_0_1, "zz-canonical")
+	for i := 0; i < 60; i++ {
+		h.Add(ip_127_0_0_1, fmt.Sprintf("h%02d%s", i, strings.Repeat("x", i%7*17)))
+	}
+
+	var alpha, compact bytes.Buffer
+	h.WriteWithOptions(&alpha, WriteOptions{})
+	h.WriteWithOptions(&compact, WriteOptions{Packing: PackingCompact})
+
+	alphaLines, compactLines := strings.Count(alpha.String(), "\n"), strings.Count(compact.String(), "\n")
+	equal(t, true, compactLines < alphaLines)
+	equal(t, true, compact.Len() < alpha.Len())
+	for _, line := range strings.Split(strings.TrimSuffix(compact.String(), "\n"), "\n") {
+		equal(t, true, len(line) <= maxLineLength)
+	}
+	equal(t, true, strings.HasPrefix(compact.String(), "127.0.0.1 zz-canonical "))
+
+	// the same mappings, deterministic output
+	r := New()
+	r.Read(strings.NewReader(compact.String()))
+	equal(t, true, h.Equal(&r))
+	equal(t, compact.Len(), int(h.ByteSize(WriteOptions{Packing: PackingCompact})))
+
+	// count limit respected
+	compact.Reset()
+	h.WriteWithOptions(&compact, WriteOptions{Packing: PackingCompact})
+	for _, line := range strings.Split(strings.TrimSuffix(compact.String(), "\n"), "\n") {
+		equal(t, true, strings.Count(line, " ") <= 9)
+	}
+	equal(t, true, strings.Count(compact.String(), "\n") <= strings.Count(h.String(), "\n"))
+}
+
+func TestWriteMaxOutputBytes(t *testing.T) {
+	h := New()
+	h.Read(strings.NewReader(exampleInput1))
+	size := h.ByteSize(WriteOptions{})
+
+	var opts WriteOptions
+	opts.MaxOutputBytes = size
+	var buf bytes.Buffer
+	equal(t, nil, h.WriteWithOptions(&buf, opts))
+	equal(t, size, int64(buf.Len()))
+	equal(t, size, h.ByteSize(opts))
+
+	// nothing is written when exceeding limit
+	buf.Reset()
+	opts.MaxOutputBytes = size - 1
+	equal(t, ErrOutputTooLarge, h.WriteWithOptions(&buf, opts))
+	equal(t, 0, buf.Len())
+	equal(t, h.Checksum(WriteOptions{}), h.Checksum(opts))
+}
@@ -0,0 +1,106 @@
+package hosts
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReadZone appends `A` and `AAAA` records read from simplified DNS zone file using provided `io.Reader`. Names
+// relative to provided origin (or the one set by `$ORIGIN` directive) are made absolute and `@` stands for origin
+// itself. Record TTL (or the one set by `$TTL` directive) is kept like with `AddWithTTL`. Other record types are
+// skipped and multi-line records are not supported.
+func (h *Hosts) ReadZone(reader io.Reader, origin string) error {
+	bufRd := bufio.NewReader(reader)
+	st := h.newReadState(false)
+	origin = strings.TrimSuffix(origin, ".")
+	var defTTL time.Duration
+	lastName := ""
+
+	for lineNum := 1; ; lineNum++ {
+		raw, tooLong, errRead := readRawLine(bufRd, h.opts.MaxLineBytes)
+		if errRead != nil && errRead != io.EOF {
+			return errRead
+		}
+		if tooLong {
+			h.skipped(st, lineNum, "", ReasonLineTooLong, nil)
+		} else if line, _, _ := strings.Cut(raw, ";"); strings.TrimSpace(line) != "" {
+			fields := strings.Fields(line)
+			switch {
+			case strings.EqualFold(fields[0], "$ORIGIN") && len(fields) > 1:
+				origin = strings.TrimSuffix(fields[1], ".")
+			case strings.EqualFold(fields[0], "$TTL") && len(fields) > 1:
+				defTTL = parseTTL(fields[1])
+			case !strings.HasPrefix(fields[0], "$"):
+				if line[0] != ' ' && line[0] != '\t' {
+					lastName, fields = zoneName(fields[0], origin), fields[1:]
+				}
+				h.readRecord(st, lineNum, raw, lastName, fields, defTTL)
+			}
+		}
+		if errRead == io.EOF {
+			break
+		}
+	}
+
+	return st.err()
+}
+
+// readRecord adds mapping of single `A` or `AAAA` record given as fields following record name.
+func (h *Hosts) readRecord(st *readState, lineNum int, raw, name string, fields []string, ttl time.Duration) {
+	for len(fields) > 0 {
+		if isDigits(fields[0]) {
+			ttl = parseTTL(fields[0])
+		} else if !strings.EqualFold(fields[0], "IN") {
+			break
+		}
+		fields = fields[1:]
+	}
+	if len(fields) < 2 || (!strings.EqualFold(fields[0], "A") && !strings.EqualFold(fields[0], "AAAA")) {
+		return
+	}
+
+	ip, errParse := ValidateIP(fields[1])
+	if errParse != nil || ip.Is4() != strings.EqualFold(fields[0], "A") {
+		h.skipped(st, lineNum, raw, ReasonInvalidIP, errParse)
+		return
+	}
+
+	accept := func(a string) {
+		if ttl > 0 {
+			h.ttl[mapping{h.normalizeIP(ip), a}] = ttl
+		}
+		if h.opts.Strict {
+			st.checkDuplicate(lineNum, h.normalizeIP(ip), a)
+		}
+	}
+	var reject func(string)
+	if h.opts.Logger != nil || h.opts.Strict {
+		reject = func(a string) { h.skipped(st, lineNum, raw, ReasonInvalidAlias, h.validateAlias(a)) }
+	}
+	h.add(ip, []string{name}, "", accept, reject)
+}
+
+// zoneName returns absolute form of record name without trailing dot.
+func zoneName(name, origin string) string {
+	switch {
+	case name == "@":
+		return origin
+	case strings.HasSuffix(name, "."):
+		return strings.TrimSuffix(name, ".")
+	case origin == "":
+		return name
+	}
+	return name + "." + origin
+}
+
+// parseTTL parses TTL given in seconds returning 0 for invalid value.
+func parseTTL(s string) time.Duration {
+	secs, errParse := strconv.ParseUint(s, 10, 32)
+	if errParse != nil {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
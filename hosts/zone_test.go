@@ -0,0 +1,50 @@
+package hosts
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+const exampleZone = `$TTL 3600
+$ORIGIN example.com.
+@           IN  SOA  ns1 admin 1 7200 3600 1209600 3600
+@           IN  A    192.168.1.1
+            IN  AAAA ::1
+www     300 IN  A    192.168.1.2 ; web server
+mail        IN  MX   10 mail
+mail        IN  A    192.168.1.3
+other.org.      A    192.168.1.4
+broken      IN  A    not-an-ip
+v6only      IN  A    ::1
+`
+
+func TestReadZone(t *testing.T) {
+	var reasons []string
+	h := NewWithOptions(Options{Logger: func(lineNum int, line, reason string) {
+		reasons = append(reasons, reason)
+	}})
+	if errRead := h.ReadZone(strings.NewReader(exampleZone), "ignored.org"); errRead != nil {
+		t.Fatal(errRead)
+	}
+
+	equalStrArr(t, []string{"192.168.1.1", "::1"}, ipArrStr(h.GetIP("example.com")))
+	equalStrArr(t, []string{"example.com"}, h.GetAlias(netip.IPv6Loopback()))
+	equal(t, []netip.Addr{ip_192_168_1_2}, h.GetIP("www.example.com"))
+	equal(t, []netip.Addr{ip_192_168_1_3}, h.GetIP("mail.example.com"))
+	equal(t, []netip.Addr{ip_192_168_1_4}, h.GetIP("other.org"))
+	equal(t, 5, h.Len())
+	equal(t, []string{ReasonInvalidIP, ReasonInvalidIP}, reasons)
+
+	// explicit TTL overrides default one
+	ttl, _ := h.TTL(ip_192_168_1_2, "www.example.com")
+	equal(t, 300*time.Second, ttl)
+	ttl, _ = h.TTL(ip_192_168_1_1, "example.com")
+	equal(t, time.Hour, ttl)
+
+	// relative names use provided origin
+	z := New()
+	z.ReadZone(strings.NewReader("host A 192.168.1.1\n"), "lan.")
+	equal(t, []netip.Addr{ip_192_168_1_1}, z.GetIP("host.lan"))
+}